@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/valyala/fasthttp"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errActivationLimitReached = errors.New("activation limit reached")
+
+// activateMachine records the HWID against the license, admitting new machines until the seat limit is reached
+// unless the limit is not enforced
+func activateMachine(tx *gorm.DB, license *models.License, hwid string, ip string, now time.Time, enforceLimit bool) error {
+	if touched, err := touchActivation(tx, license, hwid, ip, now); touched || err != nil {
+		return err
+	}
+
+	// A concurrent redemption from the same machine can insert it first, the unique index then keeps this insert out
+	var result *gorm.DB
+	if !enforceLimit {
+		result = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Activation{
+			LicenseID:     license.ID,
+			ApplicationID: license.ApplicationID,
+			HWID:          hwid,
+			IP:            ip,
+			FirstSeenOn:   now,
+			LastSeenOn:    now,
+		})
+	} else {
+		// The seats are counted by the insert itself, so concurrent redemptions on other machines cannot exceed the limit
+		result = tx.Exec(
+			"INSERT INTO activations (created_at, updated_at, license_id, application_id, hw_id, ip, first_seen_on, last_seen_on) "+
+				"SELECT ?, ?, ?, ?, ?, ?, ?, ? "+
+				"WHERE (SELECT COUNT(*) FROM activations WHERE license_id = ? AND deleted_at IS NULL) < ? "+
+				"ON CONFLICT (license_id, hw_id) DO NOTHING",
+			now, now, license.ID, license.ApplicationID, hwid, ip, now, now,
+			license.ID, license.MaxActivations,
+		)
+	}
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+
+	if touched, err := touchActivation(tx, license, hwid, ip, now); touched || err != nil {
+		return err
+	}
+	return errActivationLimitReached
+}
+
+// touchActivation records that an activated machine was seen again, reporting false when the HWID is not activated
+func touchActivation(tx *gorm.DB, license *models.License, hwid string, ip string, now time.Time) (bool, error) {
+	var activation models.Activation
+	err := tx.Where("license_id = ? AND hw_id = ?", license.ID, hwid).First(&activation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, tx.Model(&activation).Updates(models.Activation{IP: ip, LastSeenOn: now}).Error
+}
+
+// deactivateMachines removes activations from a license so their seats can be reused
+func deactivateMachines(tx *gorm.DB, license *models.License, activations []models.Activation) error {
+	if len(activations) == 0 {
+		return nil
+	}
+
+	// Hard delete so the same HWID can be activated again later
+	if err := tx.Unscoped().Delete(&activations).Error; err != nil {
+		return err
+	}
+
+	// Keep the displayed HWID pointing at a machine that is still activated
+	hwid := "N/A"
+	var next models.Activation
+	if err := tx.Where("license_id = ?", license.ID).Order("id").First(&next).Error; err == nil {
+		hwid = next.HWID
+	}
+	if license.HWID == hwid {
+		return nil
+	}
+	license.HWID = hwid
+	return tx.Model(license).Update("hw_id", hwid).Error
+}
+
+// ListActivations lists the machines a license is activated on.
+// @Summary List license activations
+// @Tags Licenses
+// @Description List the HWIDs a license is activated on
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param license_id path string true "License ID"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
+// @Success 200 {object} map[string]interface{} "OK"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/licenses/{license_id}/activations [get]
+func ListActivations(ctx *gin.Context, db *gorm.DB) {
+	licenseID := ctx.Param("license_id")
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	var license models.License
+	if err := db.Scopes(whereLicense(licenseID)).Where("application_id = ? AND user_id = ?", applicationID, userID).First(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"License not found or does not belong to the user",
+			"LICENSE_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	var activations []models.Activation
+	if err := db.Where("license_id = ?", license.ID).Order("id").Find(&activations).Error; err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to retrieve activations",
+			"ACTIVATION_RETRIEVAL_FAILED",
+			nil,
+		))
+		return
+	}
+
+	timeFormatter := utils.NewTimeFormatter(ctx)
+	response := []models.ActivationResponse{}
+	for _, activation := range activations {
+		response = append(response, models.ActivationResponse{
+			ID:          activation.ID,
+			HWID:        activation.HWID,
+			IP:          activation.IP,
+			FirstSeenOn: timeFormatter.Format(&activation.FirstSeenOn),
+			LastSeenOn:  timeFormatter.Format(&activation.LastSeenOn),
+		})
+	}
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{
+		"activations":     response,
+		"max_activations": license.MaxActivations,
+	})
+}
+
+// RevokeActivation frees a seat by removing a single machine from a license.
+// @Summary Revoke a license activation
+// @Tags Licenses
+// @Description Remove an activated HWID from a license so the seat can be reused
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param license_id path string true "License ID"
+// @Param activation_id path string true "Activation ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/licenses/{license_id}/activations/{activation_id} [delete]
+func RevokeActivation(ctx *gin.Context, db *gorm.DB) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	licenseID := ctx.Param("license_id")
+	applicationID := ctx.Param("application_id")
+	activationID := ctx.Param("activation_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	var license models.License
+	if err := db.Scopes(whereLicense(licenseID)).Where("application_id = ? AND user_id = ?", applicationID, userID).First(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"License not found or does not belong to the user",
+			"LICENSE_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	var activation models.Activation
+	if err := db.Where("id = ? AND license_id = ?", activationID, license.ID).First(&activation).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"Activation not found",
+			"ACTIVATION_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return deactivateMachines(tx, &license, []models.Activation{activation})
+	})
+	if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to revoke activation",
+			"REVOKE_FAILED",
+			nil,
+		))
+		return
+	}
+
+	// Invalidate the cache for the application's licenses
+	licensesCacheKey := "application:" + applicationID + ":licenses"
+	redisClient.Del(ctx, licensesCacheKey)
+	log.Printf("Cache invalidated for application %s licenses after activation revocation", applicationID)
+
+	ctx.JSON(fasthttp.StatusNoContent, nil)
+}
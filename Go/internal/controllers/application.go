@@ -1,9 +1,15 @@
 package controllers
 
 import (
+	"log"
+	"strings"
+	"time"
+
 	"backend/internal/models"
+	"backend/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 	"github.com/valyala/fasthttp"
 	"gorm.io/gorm"
@@ -29,10 +35,32 @@ func CreateApplication(ctx *gin.Context, db *gorm.DB) {
 
 	appID := uuid.New().String()
 
+	publicKey, privateKey, err := utils.GenerateSigningKeyPair()
+	if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, gin.H{"error": "Failed to generate signing key"})
+		return
+	}
+
+	checksumSecret, err := utils.GenerateChecksumSecret()
+	if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, gin.H{"error": "Failed to generate checksum secret"})
+		return
+	}
+
+	clientSecret, err := utils.GenerateClientSecret()
+	if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, gin.H{"error": "Failed to generate client secret"})
+		return
+	}
+
 	application := models.Application{
-		ApplicationID: appID,
-		AppName:       request.AppName,
-		UserID:        userID,
+		ApplicationID:  appID,
+		AppName:        request.AppName,
+		UserID:         userID,
+		PublicKey:      publicKey,
+		PrivateKey:     privateKey,
+		ChecksumSecret: checksumSecret,
+		ClientSecret:   clientSecret,
 	}
 
 	if err := db.Create(&application).Error; err != nil {
@@ -42,7 +70,730 @@ func CreateApplication(ctx *gin.Context, db *gorm.DB) {
 	ctx.JSON(fasthttp.StatusOK, gin.H{"application": application})
 }
 
-// Need to add:
+// GetApplicationSettings returns the policy settings of an application.
+// @Summary Get application settings
+// @Tags private
+// @Description Get the policy settings of an application owned by the authenticated user
+// @Produce json
+// @Param Authorization header string true "With the bearer started" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/private/applications/{application_id}/settings [get]
+func GetApplicationSettings(ctx *gin.Context, db *gorm.DB) {
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	var application models.Application
+	if err := db.Where("application_id = ? AND user_id = ?", applicationID, userID).First(&application).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"Application not found or does not belong to the user",
+			"APPLICATION_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{"settings": application.Settings})
+}
+
+// UpdateApplicationSettings updates the policy settings of an application.
+// @Summary Update application settings
+// @Tags private
+// @Description Update the policy settings of an application, omitted fields are left unchanged
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "With the bearer started" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param settings body models.UpdateApplicationSettingsRequest true "Settings to change"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/private/applications/{application_id}/settings [patch]
+func UpdateApplicationSettings(ctx *gin.Context, db *gorm.DB) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	request := ctx.MustGet("request").(*models.UpdateApplicationSettingsRequest)
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	var application models.Application
+	if err := db.Where("application_id = ? AND user_id = ?", applicationID, userID).First(&application).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"Application not found or does not belong to the user",
+			"APPLICATION_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	request.Apply(&application.Settings)
+	// Only the settings columns are written so secrets rotated in the meantime are kept
+	columns, err := applicationSettingsColumns(db)
+	// Applications created before requests could be signed get their client secret with the requirement,
+	// otherwise every public request would be rejected until the secret was fetched
+	if err == nil && application.Settings.RequireSignedRequests {
+		err = ensureClientSecret(db, &application)
+	}
+	if err == nil {
+		err = db.Model(&application).Select(columns).Updates(&application).Error
+	}
+	if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to update settings",
+			"UPDATE_FAILED",
+			nil,
+		))
+		return
+	}
+
+	// Invalidate the cache for the user's applications
+	applicationsCacheKey := "user:" + userID + ":applications"
+	redisClient.Del(ctx, applicationsCacheKey)
+	log.Printf("Cache invalidated for user %s applications after settings update", userID)
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{"settings": application.Settings})
+}
+
+// applicationSettingsColumns lists the columns the embedded application settings are stored in
+func applicationSettingsColumns(db *gorm.DB) ([]string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&models.Application{}); err != nil {
+		return nil, err
+	}
+	var columns []string
+	for _, field := range stmt.Schema.Fields {
+		if strings.HasPrefix(field.DBName, "setting_") {
+			columns = append(columns, field.DBName)
+		}
+	}
+	return columns, nil
+}
+
+// GetPublicKey returns the public key used to verify an application's license tokens.
+// @Summary Get application public key
+// @Tags public
+// @Description Get the Ed25519 public key for verifying the signed tokens of redeem and validate responses
+// @Produce json
+// @Param application_id path string true "Application ID"
+// @Success 200 {object} map[string]string "OK"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/public/applications/{application_id}/public-key [get]
+func GetPublicKey(ctx *gin.Context, db *gorm.DB) {
+	applicationID := ctx.Param("application_id")
+
+	var application models.Application
+	if err := db.Where("application_id = ?", applicationID).First(&application).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"Application not found",
+			"APPLICATION_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	if err := ensureSigningKeys(db, &application); err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to generate signing key",
+			"SIGNING_KEY_ERROR",
+			nil,
+		))
+		return
+	}
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{
+		"application_id": application.ApplicationID,
+		"algorithm":      "Ed25519",
+		"public_key":     application.PublicKey,
+	})
+}
+
+// ensureSigningKeys generates a signing keypair for applications created before license tokens were signed
+func ensureSigningKeys(db *gorm.DB, application *models.Application) error {
+	if application.PrivateKey != "" {
+		return nil
+	}
+
+	publicKey, privateKey, err := utils.GenerateSigningKeyPair()
+	if err != nil {
+		return err
+	}
+
+	// Only the first of concurrent callers stores its keypair, the others load the stored one
+	result := db.Model(&models.Application{}).
+		Where("application_id = ? AND (private_key IS NULL OR private_key = '')", application.ApplicationID).
+		Updates(map[string]interface{}{"public_key": publicKey, "private_key": privateKey})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return db.Select("public_key", "private_key").Where("application_id = ?", application.ApplicationID).First(application).Error
+	}
+
+	application.PublicKey = publicKey
+	application.PrivateKey = privateKey
+	return nil
+}
+
+// GetKeyChecksumSecret returns the secret client SDKs use to verify key checksums offline.
+// @Summary Get key checksum secret
+// @Tags Applications
+// @Description Get the HMAC secret and version of the checksum segment appended to license keys
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Success 200 {object} map[string]interface{} "OK"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/key-checksum [get]
+func GetKeyChecksumSecret(ctx *gin.Context, db *gorm.DB) {
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	var application models.Application
+	if err := db.Where("application_id = ? AND user_id = ?", applicationID, userID).First(&application).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"Application not found or does not belong to the user",
+			"APPLICATION_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	if err := ensureChecksumSecret(db, &application); err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to generate checksum secret",
+			"CHECKSUM_SECRET_ERROR",
+			nil,
+		))
+		return
+	}
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{
+		"application_id": application.ApplicationID,
+		"algorithm":      "HMAC-SHA256",
+		"version":        utils.KeyChecksumVersion,
+		"secret":         application.ChecksumSecret,
+	})
+}
+
+// ensureChecksumSecret generates a checksum secret for applications created before keys had checksums
+func ensureChecksumSecret(db *gorm.DB, application *models.Application) error {
+	if application.ChecksumSecret != "" {
+		return nil
+	}
+
+	checksumSecret, err := utils.GenerateChecksumSecret()
+	if err != nil {
+		return err
+	}
+
+	// Only the first of concurrent callers stores its secret, keys checksummed by the others would never verify
+	result := db.Model(&models.Application{}).
+		Where("application_id = ? AND (checksum_secret IS NULL OR checksum_secret = '')", application.ApplicationID).
+		Update("checksum_secret", checksumSecret)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return db.Select("checksum_secret").Where("application_id = ?", application.ApplicationID).First(application).Error
+	}
+
+	application.ChecksumSecret = checksumSecret
+	return nil
+}
+
+// GetClientSecret returns the secret client SDKs sign public requests with.
+// @Summary Get client secret
+// @Tags Applications
+// @Description Get the HMAC secret and headers used to sign requests to the public endpoints of the application
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
+// @Success 200 {object} map[string]interface{} "OK"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/client-secret [get]
+func GetClientSecret(ctx *gin.Context, db *gorm.DB) {
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	var application models.Application
+	if err := db.Where("application_id = ? AND user_id = ?", applicationID, userID).First(&application).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"Application not found or does not belong to the user",
+			"APPLICATION_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	if err := ensureClientSecret(db, &application); err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to generate client secret",
+			"CLIENT_SECRET_ERROR",
+			nil,
+		))
+		return
+	}
+
+	ctx.JSON(fasthttp.StatusOK, clientSecretResponse(utils.NewTimeFormatter(ctx), &application))
+}
+
+// RotateClientSecret replaces the client secret, keeping the old one valid for a transition period.
+// @Summary Rotate client secret
+// @Tags Applications
+// @Description Generate a new client secret; requests signed with the old secret are accepted until the transition period ends, 24 hours unless set
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param request body models.RotateClientSecretRequest true "Transition period, {} for the default"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
+// @Success 200 {object} map[string]interface{} "OK"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/client-secret/rotate [post]
+func RotateClientSecret(ctx *gin.Context, db *gorm.DB) {
+	request := ctx.MustGet("request").(*models.RotateClientSecretRequest)
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	var application models.Application
+	if err := db.Where("application_id = ? AND user_id = ?", applicationID, userID).First(&application).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"Application not found or does not belong to the user",
+			"APPLICATION_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	clientSecret, err := utils.GenerateClientSecret()
+	if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to generate client secret",
+			"CLIENT_SECRET_ERROR",
+			nil,
+		))
+		return
+	}
+
+	transition := models.DefaultClientSecretTransitionHours
+	if request.TransitionHours != nil {
+		transition = *request.TransitionHours
+	}
+
+	application.PreviousClientSecret = ""
+	application.PreviousClientSecretExpiresOn = nil
+	if transition > 0 && application.ClientSecret != "" {
+		expiresOn := time.Now().UTC().Add(time.Duration(transition) * time.Hour)
+		application.PreviousClientSecret = application.ClientSecret
+		application.PreviousClientSecretExpiresOn = &expiresOn
+	}
+	application.ClientSecret = clientSecret
+
+	if err := db.Model(&application).Select("ClientSecret", "PreviousClientSecret", "PreviousClientSecretExpiresOn").Updates(&application).Error; err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to rotate client secret",
+			"CLIENT_SECRET_ERROR",
+			nil,
+		))
+		return
+	}
+
+	ctx.JSON(fasthttp.StatusOK, clientSecretResponse(utils.NewTimeFormatter(ctx), &application))
+}
+
+// ensureClientSecret generates a client secret for applications created before requests could be signed
+func ensureClientSecret(db *gorm.DB, application *models.Application) error {
+	if application.ClientSecret != "" {
+		return nil
+	}
+
+	clientSecret, err := utils.GenerateClientSecret()
+	if err != nil {
+		return err
+	}
+
+	// Only the first of concurrent callers stores its secret, so no client is handed one that is replaced
+	result := db.Model(&models.Application{}).
+		Where("application_id = ? AND (client_secret IS NULL OR client_secret = '')", application.ApplicationID).
+		Update("client_secret", clientSecret)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return db.Select("client_secret").Where("application_id = ?", application.ApplicationID).First(application).Error
+	}
+
+	application.ClientSecret = clientSecret
+	return nil
+}
+
+func clientSecretResponse(timeFormatter utils.TimeFormatter, application *models.Application) gin.H {
+	response := gin.H{
+		"application_id":   application.ApplicationID,
+		"algorithm":        "HMAC-SHA256",
+		"secret":           application.ClientSecret,
+		"signature_header": utils.ClientSignatureHeader,
+		"timestamp_header": utils.ClientTimestampHeader,
+		"required":         application.Settings.RequireSignedRequests,
+	}
+	if application.PreviousClientSecretExpiresOn != nil && time.Now().Before(*application.PreviousClientSecretExpiresOn) {
+		response["previous_secret_expires_on"] = timeFormatter.Format(application.PreviousClientSecretExpiresOn)
+	}
+	return response
+}
+
+// RenameApplication changes the name of an application.
+// @Summary Rename an application
+// @Tags private
+// @Description Rename an application owned by the authenticated user
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "With the bearer started" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param application body models.RenameApplicationRequest true "New application name"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/private/applications/{application_id} [patch]
+func RenameApplication(ctx *gin.Context, db *gorm.DB) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	request := ctx.MustGet("request").(*models.RenameApplicationRequest)
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	var application models.Application
+	if err := db.Where("application_id = ? AND user_id = ?", applicationID, userID).First(&application).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"Application not found or does not belong to the user",
+			"APPLICATION_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	var duplicates int64
+	if err := db.Model(&models.Application{}).Where("user_id = ? AND app_name = ? AND application_id <> ?", userID, request.AppName, applicationID).Count(&duplicates).Error; err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to rename application",
+			"UPDATE_FAILED",
+			nil,
+		))
+		return
+	}
+	if duplicates > 0 {
+		ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+			fasthttp.StatusConflict,
+			"An application with this name already exists",
+			"APPLICATION_NAME_TAKEN",
+			nil,
+		))
+		return
+	}
+
+	if err := db.Model(&application).Update("app_name", request.AppName).Error; err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to rename application",
+			"UPDATE_FAILED",
+			nil,
+		))
+		return
+	}
+
+	// Invalidate the cache for the user's applications
+	applicationsCacheKey := "user:" + userID + ":applications"
+	redisClient.Del(ctx, applicationsCacheKey)
+	log.Printf("Cache invalidated for user %s applications after rename", userID)
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{"application": application})
+}
+
+// PauseApplication stops all licenses of an application from being used until it is resumed.
+// @Summary Pause an application
+// @Tags private
+// @Description Pause an application; redeeming, validating and every other public request for its licenses fails with APPLICATION_PAUSED
+// @Produce json
+// @Param Authorization header string true "With the bearer started" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/private/applications/{application_id}/pause [patch]
+func PauseApplication(ctx *gin.Context, db *gorm.DB) {
+	setApplicationPaused(ctx, db, true)
+}
 
-// deleteApplication
-// pauseApplication
+// ResumeApplication lets the licenses of a paused application be used again.
+// @Summary Resume an application
+// @Tags private
+// @Description Resume a paused application
+// @Produce json
+// @Param Authorization header string true "With the bearer started" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/private/applications/{application_id}/resume [patch]
+func ResumeApplication(ctx *gin.Context, db *gorm.DB) {
+	setApplicationPaused(ctx, db, false)
+}
+
+func setApplicationPaused(ctx *gin.Context, db *gorm.DB, paused bool) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	var application models.Application
+	if err := db.Where("application_id = ? AND user_id = ?", applicationID, userID).First(&application).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"Application not found or does not belong to the user",
+			"APPLICATION_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	if application.Paused == paused {
+		message, code := "Application is not paused", "APPLICATION_NOT_PAUSED"
+		if paused {
+			message, code = "Application is already paused", "APPLICATION_ALREADY_PAUSED"
+		}
+		ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+			fasthttp.StatusConflict,
+			message,
+			code,
+			nil,
+		))
+		return
+	}
+
+	var pausedOn *time.Time
+	if paused {
+		now := time.Now().UTC()
+		pausedOn = &now
+	}
+	if err := db.Model(&application).Updates(map[string]interface{}{"paused": paused, "paused_on": pausedOn}).Error; err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to update application",
+			"UPDATE_FAILED",
+			nil,
+		))
+		return
+	}
+	application.Paused = paused
+	application.PausedOn = pausedOn
+
+	// Invalidate the cache for the user's applications
+	applicationsCacheKey := "user:" + userID + ":applications"
+	redisClient.Del(ctx, applicationsCacheKey)
+	log.Printf("Cache invalidated for user %s applications after pause change", userID)
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{
+		"paused":    application.Paused,
+		"paused_on": utils.NewTimeFormatter(ctx).Format(application.PausedOn),
+	})
+}
+
+// DeleteApplication deletes an application together with its licenses and everything recorded about them.
+// @Summary Delete an application
+// @Tags private
+// @Description Permanently delete an application, its licenses, activations, bans, HWID resets, trial claims, blacklist and generation jobs
+// @Produce json
+// @Param Authorization header string true "With the bearer started" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/v1/private/applications/{application_id} [delete]
+func DeleteApplication(ctx *gin.Context, db *gorm.DB) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	var application models.Application
+	if err := db.Where("application_id = ? AND user_id = ?", applicationID, userID).First(&application).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"Application not found or does not belong to the user",
+			"APPLICATION_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	// Soft deleted licenses go too, nothing of the application is kept and its name can be reused
+	var licenseIDs []uint
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.License{}).Where("application_id = ?", applicationID).Pluck("id", &licenseIDs).Error; err != nil {
+			return err
+		}
+
+		jobs := tx.Unscoped().Model(&models.LicenseJob{}).Select("id").Where("application_id = ?", applicationID)
+		if err := tx.Unscoped().Where("license_job_id IN (?)", jobs).Delete(&models.LicenseJobChunk{}).Error; err != nil {
+			return err
+		}
+
+		// Deleting its job makes a worker running it lose its lease and roll back the chunk in progress
+		for _, model := range []interface{}{
+			&models.LicenseJob{},
+			&models.TrialClaim{},
+			&models.BlacklistEntry{},
+			&models.HWIDReset{},
+			&models.LicenseBan{},
+			&models.Activation{},
+			&models.License{},
+		} {
+			if err := tx.Unscoped().Where("application_id = ?", applicationID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Unscoped().Delete(&application).Error
+	})
+	if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to delete application",
+			"DELETE_FAILED",
+			nil,
+		))
+		return
+	}
+
+	cacheKeys := []string{"user:" + userID + ":applications", "application:" + applicationID + ":licenses"}
+	for _, licenseID := range licenseIDs {
+		cacheKeys = append(cacheKeys, sessionsKey(&models.License{Model: gorm.Model{ID: licenseID}}))
+	}
+	for start := 0; start < len(cacheKeys); start += 1000 {
+		end := start + 1000
+		if end > len(cacheKeys) {
+			end = len(cacheKeys)
+		}
+		if err := redisClient.Del(ctx, cacheKeys[start:end]...).Err(); err != nil {
+			log.Printf("Failed to delete Redis keys of application %s: %v", applicationID, err)
+		}
+	}
+	log.Printf("Cache invalidated for user %s applications and application %s licenses after application deletion", userID, applicationID)
+
+	ctx.JSON(fasthttp.StatusNoContent, nil)
+}
+
+// findPublicApplication loads the application a public request is for.
+// It writes a not found response when it does not exist, or a forbidden one when it is paused, and returns false.
+func findPublicApplication(ctx *gin.Context, db *gorm.DB, applicationID string) (*models.Application, bool) {
+	var application models.Application
+	if err := db.Where("application_id = ?", applicationID).First(&application).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"Application not found",
+			"APPLICATION_NOT_FOUND",
+			nil,
+		))
+		return nil, false
+	}
+
+	if application.Paused {
+		ctx.JSON(fasthttp.StatusForbidden, utils.NewErrorResponse(
+			fasthttp.StatusForbidden,
+			"Application is paused",
+			"APPLICATION_PAUSED",
+			nil,
+		))
+		return nil, false
+	}
+
+	return &application, true
+}
@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/valyala/fasthttp"
+	"gorm.io/gorm"
+)
+
+// UnbanLicense lifts the ban on a license and restores the status it had before.
+// @Summary Unban a license
+// @Tags Licenses
+// @Description Lift the ban on a license and restore its previous status
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param license_id path string true "License ID"
+// @Success 200 {object} map[string]string "OK"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/licenses/{license_id}/unban [patch]
+func UnbanLicense(ctx *gin.Context, db *gorm.DB) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	licenseID := ctx.Param("license_id")
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+	username := userInfo["preferred_username"].(string)
+
+	var license models.License
+	if err := db.Scopes(whereLicense(licenseID)).Where("application_id = ? AND user_id = ?", applicationID, userID).First(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"License not found or does not belong to the user",
+			"LICENSE_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	if license.Status != models.LicenseBanned {
+		ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+			fasthttp.StatusConflict,
+			"License is not banned",
+			"LICENSE_NOT_BANNED",
+			nil,
+		))
+		return
+	}
+
+	if err := unbanLicense(db, &license, username); err != nil {
+		if errors.Is(err, errLicenseStatusChanged) {
+			writeLicenseStatusChanged(ctx)
+			return
+		}
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to unban license",
+			"UNBAN_FAILED",
+			nil,
+		))
+		return
+	}
+
+	// Invalidate the cache for the application's licenses
+	licensesCacheKey := "application:" + applicationID + ":licenses"
+	redisClient.Del(ctx, licensesCacheKey)
+	log.Printf("Cache invalidated for application %s licenses after unban", applicationID)
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{"message": "License unbanned successfully", "status": license.Status})
+}
+
+// unbanLicense restores the pre-ban status of a license and closes its open ban record
+func unbanLicense(db *gorm.DB, license *models.License, unbannedBy string) error {
+	from := license.Status
+	if err := license.Transition(statusAfterBan(license)); err != nil {
+		return err
+	}
+
+	unbannedOn := time.Now().UTC()
+	license.StatusBeforeBan = ""
+	license.BannedUntil = nil
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.LicenseBan{}).
+			Where("license_id = ? AND unbanned_on IS NULL", license.ID).
+			Updates(models.LicenseBan{UnbannedBy: unbannedBy, UnbannedOn: &unbannedOn}).Error
+		if err != nil {
+			return err
+		}
+		return saveLicenseTransition(tx, license, from, "StatusBeforeBan", "BannedUntil")
+	})
+}
+
+// statusAfterBan returns the status a banned license goes back to when it is unbanned
+func statusAfterBan(license *models.License) models.LicenseStatus {
+	if license.StatusBeforeBan != "" {
+		return license.StatusBeforeBan
+	}
+	// Licenses banned before ban history existed
+	if license.UsedOn != nil {
+		return models.LicenseActive
+	}
+	return models.LicenseNotUsed
+}
+
+// banExpired reports whether the temporary ban of a license has run out
+func banExpired(license *models.License, now time.Time) bool {
+	return license.Status == models.LicenseBanned && license.BannedUntil != nil && !now.Before(*license.BannedUntil)
+}
+
+// liftExpiredBans unbans every license of an application whose temporary ban has run out, so listings do not
+// keep showing them as banned until they are next used
+func liftExpiredBans(db *gorm.DB, application *models.Application) error {
+	var licenses []models.License
+	if err := db.Where("application_id = ? AND status = ? AND banned_until <= ?", application.ApplicationID, models.LicenseBanned, time.Now().UTC()).Find(&licenses).Error; err != nil {
+		return err
+	}
+	for i := range licenses {
+		// Another request may have lifted or replaced the ban in the meantime
+		if err := unbanLicense(db, &licenses[i], "system"); err != nil && !errors.Is(err, errLicenseStatusChanged) {
+			return err
+		}
+	}
+	return nil
+}
+
+// liftExpiredBan unbans a license whose temporary ban has run out.
+// It writes the error response and returns false when the ban could not be lifted.
+func liftExpiredBan(ctx *gin.Context, db *gorm.DB, license *models.License) bool {
+	if !banExpired(license, time.Now()) {
+		return true
+	}
+
+	if err := unbanLicense(db, license, "system"); err != nil {
+		if errors.Is(err, errLicenseStatusChanged) {
+			writeLicenseStatusChanged(ctx)
+			return false
+		}
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to lift expired ban",
+			"UNBAN_FAILED",
+			nil,
+		))
+		return false
+	}
+
+	if redisClient, ok := ctx.MustGet("redisClient").(*redis.Client); ok {
+		licensesCacheKey := "application:" + license.ApplicationID + ":licenses"
+		redisClient.Del(ctx, licensesCacheKey)
+		log.Printf("Cache invalidated for application %s licenses after ban expired", license.ApplicationID)
+	}
+
+	return true
+}
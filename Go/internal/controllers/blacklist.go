@@ -0,0 +1,299 @@
+package controllers
+
+import (
+	"net/netip"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valyala/fasthttp"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AddBlacklistEntry blacklists a HWID or IP range for every license of an application.
+// @Summary Add a blacklist entry
+// @Tags Blacklist
+// @Description Stop a HWID, IP address or CIDR range from redeeming any license of the application or requesting a trial
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param request body models.BlacklistEntryRequest true "HWID or IP to blacklist"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
+// @Success 201 {object} models.BlacklistEntryResponse "Created"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/blacklist [post]
+func AddBlacklistEntry(ctx *gin.Context, db *gorm.DB) {
+	request := ctx.MustGet("request").(*models.BlacklistEntryRequest)
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+	username := userInfo["preferred_username"].(string)
+
+	if !checkApplicationOwner(ctx, db, applicationID, userID) {
+		return
+	}
+
+	value := request.Value
+	if request.Kind == models.BlacklistIP {
+		network, err := utils.ParseNetwork(value)
+		if err != nil {
+			ctx.JSON(fasthttp.StatusBadRequest, utils.NewErrorResponse(
+				fasthttp.StatusBadRequest,
+				"Invalid IP address or CIDR range",
+				"INVALID_IP",
+				nil,
+			))
+			return
+		}
+		value = network.String()
+	}
+
+	entry := models.BlacklistEntry{
+		ApplicationID: applicationID,
+		Kind:          request.Kind,
+		Value:         value,
+		Reason:        request.Reason,
+		AddedBy:       username,
+		AddedOn:       time.Now().UTC(),
+	}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry)
+	if result.Error != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to add blacklist entry",
+			"BLACKLIST_UPDATE_FAILED",
+			nil,
+		))
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+			fasthttp.StatusConflict,
+			"Already blacklisted",
+			"BLACKLIST_ENTRY_EXISTS",
+			map[string]string{"kind": string(entry.Kind), "value": entry.Value},
+		))
+		return
+	}
+
+	ctx.JSON(fasthttp.StatusCreated, blacklistEntryResponse(utils.NewTimeFormatter(ctx), &entry))
+}
+
+// ListBlacklist lists the blacklisted HWIDs and IPs of an application.
+// @Summary List blacklist entries
+// @Tags Blacklist
+// @Description List the blacklisted HWIDs and IPs of an application, newest first
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param kind query string false "Only list entries of this kind, hwid or ip"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
+// @Success 200 {object} map[string]interface{} "OK"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/blacklist [get]
+func ListBlacklist(ctx *gin.Context, db *gorm.DB) {
+	applicationID := ctx.Param("application_id")
+	kind := models.BlacklistKind(ctx.Query("kind"))
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	if kind != "" && kind != models.BlacklistHWID && kind != models.BlacklistIP {
+		ctx.JSON(fasthttp.StatusBadRequest, utils.NewErrorResponse(
+			fasthttp.StatusBadRequest,
+			"Invalid parameter",
+			"INVALID_PARAMETER",
+			map[string]string{"parameter": "kind", "error": "must be hwid or ip"},
+		))
+		return
+	}
+
+	if !checkApplicationOwner(ctx, db, applicationID, userID) {
+		return
+	}
+
+	query := db.Where("application_id = ?", applicationID)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	var entries []models.BlacklistEntry
+	if err := query.Order("id DESC").Find(&entries).Error; err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to retrieve blacklist",
+			"BLACKLIST_RETRIEVAL_FAILED",
+			nil,
+		))
+		return
+	}
+
+	timeFormatter := utils.NewTimeFormatter(ctx)
+	response := []models.BlacklistEntryResponse{}
+	for i := range entries {
+		response = append(response, blacklistEntryResponse(timeFormatter, &entries[i]))
+	}
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{"entries": response})
+}
+
+// RemoveBlacklistEntry lifts a blacklist entry.
+// @Summary Remove a blacklist entry
+// @Tags Blacklist
+// @Description Remove a HWID or IP from the blacklist of an application
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param entry_id path string true "Blacklist entry ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/blacklist/{entry_id} [delete]
+func RemoveBlacklistEntry(ctx *gin.Context, db *gorm.DB) {
+	applicationID := ctx.Param("application_id")
+	entryID := ctx.Param("entry_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	if !checkApplicationOwner(ctx, db, applicationID, userID) {
+		return
+	}
+
+	// Hard delete so the same HWID or IP can be blacklisted again later
+	result := db.Unscoped().Where("id = ? AND application_id = ?", entryID, applicationID).Delete(&models.BlacklistEntry{})
+	if result.Error != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to remove blacklist entry",
+			"BLACKLIST_UPDATE_FAILED",
+			nil,
+		))
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"Blacklist entry not found",
+			"BLACKLIST_ENTRY_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	ctx.JSON(fasthttp.StatusNoContent, nil)
+}
+
+// checkApplicationOwner writes a not found response and returns false unless the user owns the application
+func checkApplicationOwner(ctx *gin.Context, db *gorm.DB, applicationID string, userID string) bool {
+	var application models.Application
+	if err := db.Select("application_id").Where("application_id = ? AND user_id = ?", applicationID, userID).First(&application).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"Application not found or does not belong to the user",
+			"APPLICATION_NOT_FOUND",
+			nil,
+		))
+		return false
+	}
+	return true
+}
+
+// checkBlacklist writes a forbidden response and returns false when the HWID or IP is blacklisted by the application
+func checkBlacklist(ctx *gin.Context, db *gorm.DB, applicationID string, hwid string, ip string) bool {
+	var hwidEntries int64
+	if err := db.Model(&models.BlacklistEntry{}).Where("application_id = ? AND kind = ? AND value = ?", applicationID, models.BlacklistHWID, hwid).Count(&hwidEntries).Error; err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to check blacklist",
+			"BLACKLIST_CHECK_FAILED",
+			nil,
+		))
+		return false
+	}
+	if hwidEntries > 0 {
+		ctx.JSON(fasthttp.StatusForbidden, utils.NewErrorResponse(
+			fasthttp.StatusForbidden,
+			"HWID is blacklisted",
+			"HWID_BLACKLISTED",
+			nil,
+		))
+		return false
+	}
+
+	var networks []string
+	if err := db.Model(&models.BlacklistEntry{}).Where("application_id = ? AND kind = ?", applicationID, models.BlacklistIP).Pluck("value", &networks).Error; err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to check blacklist",
+			"BLACKLIST_CHECK_FAILED",
+			nil,
+		))
+		return false
+	}
+	if utils.IPInNetworks(ip, networks) {
+		ctx.JSON(fasthttp.StatusForbidden, utils.NewErrorResponse(
+			fasthttp.StatusForbidden,
+			"IP is blacklisted",
+			"IP_BLACKLISTED",
+			nil,
+		))
+		return false
+	}
+
+	return true
+}
+
+// blacklistLicenseMachines blacklists the HWIDs a banned license is activated on and the IP it was last used from.
+// Entries that already exist are kept as they are.
+func blacklistLicenseMachines(tx *gorm.DB, license *models.License, reason string, addedBy string, addedOn time.Time) error {
+	var hwids []string
+	if err := tx.Model(&models.Activation{}).Where("license_id = ?", license.ID).Pluck("hw_id", &hwids).Error; err != nil {
+		return err
+	}
+
+	entries := make([]models.BlacklistEntry, 0, len(hwids)+1)
+	for _, hwid := range hwids {
+		entries = append(entries, models.BlacklistEntry{Kind: models.BlacklistHWID, Value: hwid})
+	}
+	if addr, err := utils.ParseClientIP(license.IP); err == nil {
+		entries = append(entries, models.BlacklistEntry{Kind: models.BlacklistIP, Value: netip.PrefixFrom(addr, addr.BitLen()).String()})
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	for i := range entries {
+		entries[i].ApplicationID = license.ApplicationID
+		entries[i].Reason = reason
+		entries[i].AddedBy = addedBy
+		entries[i].AddedOn = addedOn
+		entries[i].LicenseID = &license.ID
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entries).Error
+}
+
+func blacklistEntryResponse(timeFormatter utils.TimeFormatter, entry *models.BlacklistEntry) models.BlacklistEntryResponse {
+	return models.BlacklistEntryResponse{
+		ID:        entry.ID,
+		Kind:      entry.Kind,
+		Value:     entry.Value,
+		Reason:    entry.Reason,
+		AddedBy:   entry.AddedBy,
+		AddedOn:   timeFormatter.Format(&entry.AddedOn),
+		LicenseID: entry.LicenseID,
+	}
+}
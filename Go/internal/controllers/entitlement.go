@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"log"
+
+	"backend/internal/models"
+	"backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/valyala/fasthttp"
+	"gorm.io/gorm"
+)
+
+// UpdateLicenseEntitlements replaces the features and limits granted to a license.
+// @Summary Update license entitlements
+// @Tags Licenses
+// @Description Replace the entitlements of a license; every entitlement must be declared by the application
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param license_id path string true "License ID"
+// @Param request body models.UpdateLicenseEntitlementsRequest true "Entitlements of the license"
+// @Success 200 {object} map[string]interface{} "OK"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/licenses/{license_id}/entitlements [patch]
+func UpdateLicenseEntitlements(ctx *gin.Context, db *gorm.DB) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	request := ctx.MustGet("request").(*models.UpdateLicenseEntitlementsRequest)
+	licenseID := ctx.Param("license_id")
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	var application models.Application
+	if err := db.Where("application_id = ? AND user_id = ?", applicationID, userID).First(&application).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"Application not found or does not belong to the user",
+			"APPLICATION_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	var license models.License
+	if err := db.Scopes(whereLicense(licenseID)).Where("application_id = ? AND user_id = ?", applicationID, userID).First(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"License not found or does not belong to the user",
+			"LICENSE_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	if err := application.Settings.CheckEntitlements(request.Entitlements); err != nil {
+		ctx.JSON(fasthttp.StatusBadRequest, utils.NewErrorResponse(
+			fasthttp.StatusBadRequest,
+			"Invalid entitlements",
+			"INVALID_ENTITLEMENTS",
+			err.Error(),
+		))
+		return
+	}
+
+	license.Entitlements = request.Entitlements.Compact()
+	if err := db.Model(&license).Select("entitlements").Updates(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to update entitlements",
+			"UPDATE_FAILED",
+			nil,
+		))
+		return
+	}
+
+	// Invalidate the cache for the application's licenses
+	licensesCacheKey := "application:" + applicationID + ":licenses"
+	redisClient.Del(ctx, licensesCacheKey)
+	log.Printf("Cache invalidated for application %s licenses after entitlement update", applicationID)
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{"message": "Entitlements updated successfully", "entitlements": license.Entitlements})
+}
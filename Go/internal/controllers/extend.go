@@ -0,0 +1,249 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/valyala/fasthttp"
+	"gorm.io/gorm"
+)
+
+var (
+	errLifetimeLicense = errors.New("lifetime licenses cannot be extended")
+	errRevokedLicense  = errors.New("revoked licenses cannot be extended")
+)
+
+// ExtendLicense adds time to a single license.
+// @Summary Extend a license
+// @Tags Licenses
+// @Description Add time to a license; unused licenses get a longer duration, used ones a later expiry
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param license_id path string true "License ID"
+// @Param request body models.ExtendLicenseRequest true "Time to add"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
+// @Success 200 {object} map[string]interface{} "OK"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/licenses/{license_id}/extend [patch]
+func ExtendLicense(ctx *gin.Context, db *gorm.DB) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	request := ctx.MustGet("request").(*models.ExtendLicenseRequest)
+	licenseID := ctx.Param("license_id")
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	var license models.License
+	if err := db.Scopes(whereLicense(licenseID)).Where("application_id = ? AND user_id = ?", applicationID, userID).First(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"License not found or does not belong to the user",
+			"LICENSE_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	if err := extendLicense(db, &license, request.Duration, request.ExpiryUnit); err == errLifetimeLicense {
+		ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+			fasthttp.StatusConflict,
+			"Lifetime licenses cannot be extended",
+			"LICENSE_LIFETIME",
+			nil,
+		))
+		return
+	} else if err == errRevokedLicense {
+		ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+			fasthttp.StatusConflict,
+			"Revoked licenses cannot be extended",
+			"LICENSE_REVOKED",
+			nil,
+		))
+		return
+	} else if errors.Is(err, errLicenseStatusChanged) {
+		writeLicenseStatusChanged(ctx)
+		return
+	} else if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to extend license",
+			"EXTEND_FAILED",
+			nil,
+		))
+		return
+	}
+
+	// Invalidate the cache for the application's licenses
+	licensesCacheKey := "application:" + applicationID + ":licenses"
+	redisClient.Del(ctx, licensesCacheKey)
+	log.Printf("Cache invalidated for application %s licenses after extension", applicationID)
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{"license": models.ExtendedLicenseResponse{
+		ID:        license.ID,
+		KeyHint:   license.KeyHint,
+		Duration:  license.Duration,
+		ExpiresOn: formatExpiry(utils.NewTimeFormatter(ctx), &license),
+		Status:    license.Status,
+	}})
+}
+
+// ExtendLicenses adds time to multiple licenses.
+// @Summary Extend multiple licenses
+// @Tags Licenses
+// @Description Add time to a set of licenses; unused licenses get a longer duration, used ones a later expiry
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param request body models.ExtendLicensesRequest true "License keys and time to add"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
+// @Success 200 {object} map[string]interface{} "OK"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/licenses-extend [patch]
+func ExtendLicenses(ctx *gin.Context, db *gorm.DB) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	request := ctx.MustGet("request").(*models.ExtendLicensesRequest)
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	timeFormatter := utils.NewTimeFormatter(ctx)
+	extended := []models.ExtendedLicenseResponse{}
+	var licenses []models.License
+	skippedIDs := make(map[uint]bool)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(whereLicenses(request.Keys)).Where("application_id = ? AND user_id = ?", applicationID, userID).Find(&licenses).Error; err != nil {
+			return err
+		}
+
+		for i := range licenses {
+			// Lifetime and revoked licenses are reported as skipped rather than failing the whole batch
+			if licenses[i].Lifetime || licenses[i].Status == models.LicenseRevoked {
+				skippedIDs[licenses[i].ID] = true
+				continue
+			}
+			if err := extendLicense(tx, &licenses[i], request.Duration, request.ExpiryUnit); err != nil {
+				return err
+			}
+			extended = append(extended, models.ExtendedLicenseResponse{
+				ID:        licenses[i].ID,
+				KeyHint:   licenses[i].KeyHint,
+				Duration:  licenses[i].Duration,
+				ExpiresOn: formatExpiry(timeFormatter, &licenses[i]),
+				Status:    licenses[i].Status,
+			})
+		}
+		return nil
+	})
+	if errors.Is(err, errLicenseStatusChanged) {
+		writeLicenseStatusChanged(ctx)
+		return
+	} else if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to extend licenses",
+			"EXTEND_FAILED",
+			nil,
+		))
+		return
+	}
+
+	// Skipped and missing licenses are reported with the identifiers they were requested by
+	skipped := []string{}
+	notFound := []string{}
+	for _, identifier := range request.Keys {
+		found := false
+		for i := range licenses {
+			if identifiesLicense(&licenses[i], identifier) {
+				found = true
+				if skippedIDs[licenses[i].ID] {
+					skipped = append(skipped, identifier)
+				}
+				break
+			}
+		}
+		if !found {
+			notFound = append(notFound, identifier)
+		}
+	}
+
+	// Invalidate the cache for the application's licenses
+	licensesCacheKey := "application:" + applicationID + ":licenses"
+	redisClient.Del(ctx, licensesCacheKey)
+	log.Printf("Cache invalidated for application %s licenses after extension", applicationID)
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{"licenses": extended, "skipped": skipped, "not_found": notFound})
+}
+
+// extendLicense adds time to a license. Unused licenses get a longer duration, used licenses a later expiry,
+// counted from now when they have already expired so that expired licenses are revived.
+func extendLicense(db *gorm.DB, license *models.License, amount int, unit string) error {
+	if license.Lifetime {
+		return errLifetimeLicense
+	}
+	if license.Status == models.LicenseRevoked {
+		return errRevokedLicense
+	}
+
+	from := license.Status
+
+	license.Duration = utils.ExtendDurationText(license.Duration, amount, unit)
+
+	if license.UsedOn != nil {
+		// A frozen license's clock is stopped, so its expiry is extended as is
+		expiresOn := time.Now().UTC()
+		if license.ExpiresOn != nil && (license.Status == models.LicenseFrozen || license.ExpiresOn.After(expiresOn)) {
+			expiresOn = *license.ExpiresOn
+		}
+
+		expiresOn, err := utils.AddDuration(expiresOn, amount, unit)
+		if err != nil {
+			return err
+		}
+		license.ExpiresOn = &expiresOn
+	}
+
+	if license.Status == models.LicenseExpired {
+		if err := license.Transition(models.LicenseActive); err != nil {
+			return err
+		}
+	}
+
+	return saveLicenseTransition(db, license, from, "Duration", "ExpiresOn")
+}
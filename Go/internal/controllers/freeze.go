@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/valyala/fasthttp"
+	"gorm.io/gorm"
+)
+
+// FreezeLicense suspends a license so it cannot be used and its remaining time stops counting.
+// @Summary Freeze a license
+// @Tags Licenses
+// @Description Suspend a license without consuming its remaining time
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param license_id path string true "License ID"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
+// @Success 200 {object} map[string]string "OK"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 410 {object} map[string]string "Gone"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/licenses/{license_id}/freeze [patch]
+func FreezeLicense(ctx *gin.Context, db *gorm.DB) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	licenseID := ctx.Param("license_id")
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	var license models.License
+	if err := db.Scopes(whereLicense(licenseID)).Where("application_id = ? AND user_id = ?", applicationID, userID).First(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"License not found or does not belong to the user",
+			"LICENSE_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	var application models.Application
+	if err := db.Where("application_id = ?", applicationID).First(&application).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"Application not found",
+			"APPLICATION_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	// A license in its grace period still works, so it can still be frozen
+	if license.Status == models.LicenseActive && license.ExpiresOn != nil && !checkLicenseExpiry(ctx, db, &application, &license) {
+		return
+	}
+
+	statusBeforeFreeze := license.Status
+	if !transitionLicense(ctx, &license, models.LicenseFrozen) {
+		return
+	}
+	license.StatusBeforeFreeze = statusBeforeFreeze
+	frozenOn := time.Now().UTC()
+	license.FrozenOn = &frozenOn
+
+	if err := saveLicenseTransition(db, &license, statusBeforeFreeze, "StatusBeforeFreeze", "FrozenOn"); err != nil {
+		if errors.Is(err, errLicenseStatusChanged) {
+			writeLicenseStatusChanged(ctx)
+			return
+		}
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to freeze license",
+			"FREEZE_FAILED",
+			nil,
+		))
+		return
+	}
+
+	// Invalidate the cache for the application's licenses
+	licensesCacheKey := "application:" + applicationID + ":licenses"
+	redisClient.Del(ctx, licensesCacheKey)
+	log.Printf("Cache invalidated for application %s licenses after freeze", applicationID)
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{"message": "License frozen successfully", "frozen_on": utils.NewTimeFormatter(ctx).Format(license.FrozenOn)})
+}
+
+// ResumeLicense unfreezes a license and pushes its expiry forward by the time it spent frozen.
+// @Summary Resume a frozen license
+// @Tags Licenses
+// @Description Unfreeze a license and extend its expiry by the frozen interval
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param license_id path string true "License ID"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
+// @Success 200 {object} map[string]string "OK"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/licenses/{license_id}/resume [patch]
+func ResumeLicense(ctx *gin.Context, db *gorm.DB) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	licenseID := ctx.Param("license_id")
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	var license models.License
+	if err := db.Scopes(whereLicense(licenseID)).Where("application_id = ? AND user_id = ?", applicationID, userID).First(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"License not found or does not belong to the user",
+			"LICENSE_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	if license.Status != models.LicenseFrozen {
+		ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+			fasthttp.StatusConflict,
+			"License is not frozen",
+			"LICENSE_NOT_FROZEN",
+			nil,
+		))
+		return
+	}
+
+	// Used licenses have their expiry moved, unused ones keep the frozen time for expiry counted from creation
+	if license.FrozenOn != nil && license.ExpiresOn != nil {
+		expiresOn := license.ExpiresOn.Add(time.Since(*license.FrozenOn))
+		license.ExpiresOn = &expiresOn
+	} else if license.FrozenOn != nil {
+		license.FrozenSeconds += int64(time.Since(*license.FrozenOn).Seconds())
+	}
+
+	if !transitionLicense(ctx, &license, license.StatusBeforeFreeze) {
+		return
+	}
+	license.StatusBeforeFreeze = ""
+	license.FrozenOn = nil
+
+	if err := saveLicenseTransition(db, &license, models.LicenseFrozen, "StatusBeforeFreeze", "FrozenOn", "FrozenSeconds", "ExpiresOn"); err != nil {
+		if errors.Is(err, errLicenseStatusChanged) {
+			writeLicenseStatusChanged(ctx)
+			return
+		}
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to resume license",
+			"RESUME_FAILED",
+			nil,
+		))
+		return
+	}
+
+	// Invalidate the cache for the application's licenses
+	licensesCacheKey := "application:" + applicationID + ":licenses"
+	redisClient.Del(ctx, licensesCacheKey)
+	log.Printf("Cache invalidated for application %s licenses after resume", applicationID)
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{"message": "License resumed successfully", "status": license.Status, "expires_on": formatExpiry(utils.NewTimeFormatter(ctx), &license)})
+}
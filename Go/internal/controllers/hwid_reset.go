@@ -0,0 +1,366 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/valyala/fasthttp"
+	"gorm.io/gorm"
+)
+
+// ResetLicenseHWID removes every machine bound to a license so the customer can activate new hardware.
+// @Summary Reset license HWID
+// @Tags Licenses
+// @Description Remove all activated HWIDs from a license while keeping its history and expiry
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param license_id path string true "License ID"
+// @Success 200 {object} map[string]string "OK"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/licenses/{license_id}/reset-hwid [patch]
+func ResetLicenseHWID(ctx *gin.Context, db *gorm.DB) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	licenseID := ctx.Param("license_id")
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+	username := userInfo["preferred_username"].(string)
+
+	var license models.License
+	if err := db.Scopes(whereLicense(licenseID)).Where("application_id = ? AND user_id = ?", applicationID, userID).First(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"License not found or does not belong to the user",
+			"LICENSE_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	var activations []models.Activation
+	if err := db.Where("license_id = ?", license.ID).Find(&activations).Error; err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to retrieve activations",
+			"ACTIVATION_RETRIEVAL_FAILED",
+			nil,
+		))
+		return
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return resetHWIDs(tx, &license, activations, false, username, utils.GetClientIP(ctx))
+	})
+	if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to reset HWID",
+			"HWID_RESET_FAILED",
+			nil,
+		))
+		return
+	}
+
+	// Invalidate the cache for the application's licenses
+	licensesCacheKey := "application:" + applicationID + ":licenses"
+	redisClient.Del(ctx, licensesCacheKey)
+	log.Printf("Cache invalidated for application %s licenses after HWID reset", applicationID)
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{"message": "HWID reset successfully"})
+}
+
+// ResetOwnHWID lets a customer move their license to new hardware, limited by the application's reset policy.
+// @Summary Reset HWID as a customer
+// @Tags Licenses
+// @Description Remove the given HWID from a license, authenticated by the license key and the old HWID
+// @Accept json
+// @Produce json
+// @Param X-Signature header string false "HMAC-SHA256 request signature, required when the application requires signed requests"
+// @Param X-Signature-Timestamp header string false "Unix timestamp the request was signed at"
+// @Param application_id path string true "Application ID"
+// @Param request body models.ResetHWIDRequest true "License key and the HWID to reset"
+// @Success 200 {object} map[string]string "OK"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 429 {object} map[string]string "Too Many Requests"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/public/applications/{application_id}/reset-hwid [post]
+func ResetOwnHWID(ctx *gin.Context, db *gorm.DB) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	request := ctx.MustGet("request").(*models.ResetHWIDRequest)
+	applicationID := ctx.Param("application_id")
+
+	application, ok := findPublicApplication(ctx, db, applicationID)
+	if !ok {
+		return
+	}
+
+	if !checkBlacklist(ctx, db, applicationID, request.HWID, utils.GetClientIP(ctx)) {
+		return
+	}
+
+	settings := application.Settings
+	if !settings.HWIDResetEnabled {
+		ctx.JSON(fasthttp.StatusForbidden, utils.NewErrorResponse(
+			fasthttp.StatusForbidden,
+			"HWID reset is disabled for this application",
+			"HWID_RESET_DISABLED",
+			nil,
+		))
+		return
+	}
+
+	var license models.License
+	if err := db.Where("key_hash = ? AND application_id = ?", utils.HashLicenseKey(request.Key), applicationID).First(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"License not found",
+			"LICENSE_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	// Banned, frozen, revoked or expired licenses cannot move to new machines
+	if !checkLicenseUsable(ctx, db, application, &license) {
+		return
+	}
+
+	var activation models.Activation
+	if err := db.Where("license_id = ? AND hw_id = ?", license.ID, request.HWID).First(&activation).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"HWID is not activated on this license",
+			"ACTIVATION_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	var lastReset models.HWIDReset
+	if settings.HWIDResetCooldownHours > 0 {
+		err := db.Where("license_id = ? AND self_service = ?", license.ID, true).Order("created_at DESC").First(&lastReset).Error
+		cooldownEnds := lastReset.CreatedAt.Add(time.Duration(settings.HWIDResetCooldownHours) * time.Hour)
+		if err == nil && time.Now().Before(cooldownEnds) {
+			ctx.JSON(fasthttp.StatusTooManyRequests, utils.NewErrorResponse(
+				fasthttp.StatusTooManyRequests,
+				"HWID was reset too recently",
+				"HWID_RESET_COOLDOWN",
+				map[string]string{"retry_after": cooldownEnds.UTC().Format(time.RFC3339)},
+			))
+			return
+		}
+	}
+
+	if settings.HWIDResetLimit > 0 {
+		query := db.Model(&models.HWIDReset{}).Where("license_id = ? AND self_service = ?", license.ID, true)
+		if settings.HWIDResetPeriodDays > 0 {
+			query = query.Where("created_at > ?", time.Now().AddDate(0, 0, -settings.HWIDResetPeriodDays))
+		}
+
+		var resets int64
+		if err := query.Count(&resets).Error; err != nil {
+			ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+				fasthttp.StatusInternalServerError,
+				"Failed to retrieve HWID resets",
+				"HWID_RESET_RETRIEVAL_FAILED",
+				nil,
+			))
+			return
+		}
+		if resets >= int64(settings.HWIDResetLimit) {
+			ctx.JSON(fasthttp.StatusTooManyRequests, utils.NewErrorResponse(
+				fasthttp.StatusTooManyRequests,
+				"HWID reset limit reached",
+				"HWID_RESET_LIMIT_REACHED",
+				map[string]int{"limit": settings.HWIDResetLimit, "period_days": settings.HWIDResetPeriodDays},
+			))
+			return
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := recordSelfServiceReset(tx, &settings, &license, &activation, utils.GetClientIP(ctx)); err != nil {
+			return err
+		}
+		return deactivateMachines(tx, &license, []models.Activation{activation})
+	})
+	if err == errHWIDResetNotAllowed {
+		ctx.JSON(fasthttp.StatusTooManyRequests, utils.NewErrorResponse(
+			fasthttp.StatusTooManyRequests,
+			"HWID reset limit or cooldown reached",
+			"HWID_RESET_LIMIT_REACHED",
+			map[string]int{"limit": settings.HWIDResetLimit, "period_days": settings.HWIDResetPeriodDays, "cooldown_hours": settings.HWIDResetCooldownHours},
+		))
+		return
+	} else if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to reset HWID",
+			"HWID_RESET_FAILED",
+			nil,
+		))
+		return
+	}
+
+	// Invalidate the cache for the application's licenses
+	licensesCacheKey := "application:" + applicationID + ":licenses"
+	redisClient.Del(ctx, licensesCacheKey)
+	log.Printf("Cache invalidated for application %s licenses after HWID reset", applicationID)
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{"message": "HWID reset successfully"})
+}
+
+// ListHWIDResets returns the reset history of a license.
+// @Summary List HWID resets
+// @Tags Licenses
+// @Description List every HWID reset performed on a license
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param license_id path string true "License ID"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
+// @Success 200 {object} map[string]interface{} "OK"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/licenses/{license_id}/hwid-resets [get]
+func ListHWIDResets(ctx *gin.Context, db *gorm.DB) {
+	licenseID := ctx.Param("license_id")
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	var license models.License
+	if err := db.Scopes(whereLicense(licenseID)).Where("application_id = ? AND user_id = ?", applicationID, userID).First(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"License not found or does not belong to the user",
+			"LICENSE_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	var resets []models.HWIDReset
+	if err := db.Where("license_id = ?", license.ID).Order("created_at DESC").Find(&resets).Error; err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to retrieve HWID resets",
+			"HWID_RESET_RETRIEVAL_FAILED",
+			nil,
+		))
+		return
+	}
+
+	timeFormatter := utils.NewTimeFormatter(ctx)
+	response := []models.HWIDResetResponse{}
+	for _, reset := range resets {
+		response = append(response, models.HWIDResetResponse{
+			HWID:        reset.HWID,
+			IP:          reset.IP,
+			SelfService: reset.SelfService,
+			ResetBy:     reset.ResetBy,
+			ResetOn:     timeFormatter.Format(&reset.ResetOn),
+		})
+	}
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{"hwid_resets": response})
+}
+
+var errHWIDResetNotAllowed = errors.New("HWID reset limit or cooldown reached")
+
+// recordSelfServiceReset records a customer reset unless the application's cooldown or limit forbids it.
+// The previous resets are checked by the insert itself, so concurrent requests cannot exceed either.
+func recordSelfServiceReset(tx *gorm.DB, settings *models.ApplicationSettings, license *models.License, activation *models.Activation, ip string) error {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(&models.HWIDReset{}); err != nil {
+		return err
+	}
+	table := stmt.Schema.Table
+
+	now := time.Now()
+	conditions := []string{"1 = 1"}
+	var conditionArgs []interface{}
+	if settings.HWIDResetCooldownHours > 0 {
+		conditions = append(conditions, "NOT EXISTS (SELECT 1 FROM "+table+" WHERE license_id = ? AND self_service = ? AND deleted_at IS NULL AND created_at > ?)")
+		conditionArgs = append(conditionArgs, license.ID, true, now.Add(-time.Duration(settings.HWIDResetCooldownHours)*time.Hour))
+	}
+	if settings.HWIDResetLimit > 0 {
+		periodStart := time.Time{}
+		if settings.HWIDResetPeriodDays > 0 {
+			periodStart = now.AddDate(0, 0, -settings.HWIDResetPeriodDays)
+		}
+		conditions = append(conditions, "(SELECT COUNT(*) FROM "+table+" WHERE license_id = ? AND self_service = ? AND deleted_at IS NULL AND created_at > ?) < ?")
+		conditionArgs = append(conditionArgs, license.ID, true, periodStart, settings.HWIDResetLimit)
+	}
+
+	args := []interface{}{now, now, license.ID, license.ApplicationID, activation.HWID, ip, true, "customer", now.UTC()}
+	result := tx.Exec(
+		"INSERT INTO "+table+" (created_at, updated_at, license_id, application_id, hw_id, ip, self_service, reset_by, reset_on) "+
+			"SELECT ?, ?, ?, ?, ?, ?, ?, ?, ? WHERE "+strings.Join(conditions, " AND "),
+		append(args, conditionArgs...)...,
+	)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errHWIDResetNotAllowed
+	}
+	return nil
+}
+
+// resetHWIDs removes the given activations and records a reset for each of them
+func resetHWIDs(tx *gorm.DB, license *models.License, activations []models.Activation, selfService bool, resetBy string, ip string) error {
+	resetOn := time.Now().UTC()
+	for _, activation := range activations {
+		reset := models.HWIDReset{
+			LicenseID:     license.ID,
+			ApplicationID: license.ApplicationID,
+			HWID:          activation.HWID,
+			IP:            ip,
+			SelfService:   selfService,
+			ResetBy:       resetBy,
+			ResetOn:       resetOn,
+		}
+		if err := tx.Create(&reset).Error; err != nil {
+			return err
+		}
+	}
+
+	return deactivateMachines(tx, license, activations)
+}
@@ -1,7 +1,10 @@
 package controllers
 
 import (
+	"errors"
 	"log"
+	"math"
+	"strconv"
 	"time"
 
 	"backend/internal/models"
@@ -22,6 +25,7 @@ import (
 // @Param Authorization header string true "Bearer token" default(Bearer <token>)
 // @Param application_id path string true "Application ID"
 // @Param request body models.LicenseRequest true "License generation data"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
 // @Success 201 {object} map[string]interface{} "Created"
 // @Failure 400 {object} map[string]string "Bad Request"
 // @Failure 401 {object} map[string]string "Unauthorized"
@@ -44,7 +48,8 @@ func GenerateLicense(ctx *gin.Context, db *gorm.DB) {
 	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
 	userID := userInfo["sub"].(string)
 	username := userInfo["preferred_username"].(string)
-	currentDateTime := utils.GetCurrentDatetime()
+	createdOn := time.Now().UTC()
+	timeFormatter := utils.NewTimeFormatter(ctx)
 
 	var application models.Application
 	if err := db.Where("application_id = ? AND user_id = ?", applicationID, userID).First(&application).Error; err != nil {
@@ -57,38 +62,48 @@ func GenerateLicense(ctx *gin.Context, db *gorm.DB) {
 		return
 	}
 
+	generation, err := newLicenseGeneration(db, &application, request, username)
+	if err != nil {
+		writeLicenseGenerationError(ctx, err)
+		return
+	}
+
+	keys, err := generateUniqueKeys(db, applicationID, request.Prefix, generation.keyMask, generation.checksumSecret, request.LicenseAmount)
+	if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to generate license keys",
+			"KEY_GENERATION_FAILED",
+			nil,
+		))
+		return
+	}
+
 	var dbLicenses []models.License
 	var licenses []models.LicenseResponse
-	for i := 0; i < request.LicenseAmount; i++ {
-		key := utils.GenerateLicenseKey(request.Prefix, request.LicenseMask)
+	for _, key := range keys {
 		licenseData := models.LicenseResponse{
-			Key:         key,
-			Note:        request.LicenseNote,
-			CreatedOn:   currentDateTime,
-			Duration:    utils.FormatDuration(request.LicenseDuration, request.LicenseExpiryUnit),
-			GeneratedBy: username,
-			UsedOn:      "N/A",
-			ExpiresOn:   "N/A",
-			Status:      "Not Used",
-			IP:          "N/A",
-			HWID:        "N/A",
-		}
-
-		dbLicenses = append(dbLicenses, models.License{
-			UserID:        userID,
-			ApplicationID: applicationID,
-			Key:           key,
-			Note:          request.LicenseNote,
-			CreatedOn:     currentDateTime,
-			Duration:      utils.FormatDuration(request.LicenseDuration, request.LicenseExpiryUnit),
-			GeneratedBy:   username,
-			UsedOn:        "N/A",
-			ExpiresOn:     "N/A",
-			Status:        "Not Used",
-			IP:            "N/A",
-			HWID:          "N/A",
-		})
+			Key:            key,
+			KeyHint:        utils.LicenseKeyHint(key),
+			KeyVersion:     generation.keyVersion,
+			Note:           request.LicenseNote,
+			CreatedOn:      timeFormatter.Format(&createdOn),
+			Duration:       generation.duration,
+			GeneratedBy:    username,
+			UsedOn:         timeFormatter.FormatOr(nil, "N/A"),
+			ExpiresOn:      timeFormatter.FormatOr(nil, "N/A"),
+			LastSeenOn:     timeFormatter.FormatOr(nil, "N/A"),
+			Status:         models.LicenseNotUsed,
+			IP:             "N/A",
+			HWID:           "N/A",
+			MaxActivations: generation.maxActivations,
+			MaxSessions:    request.MaxSessions,
+			UsageQuota:     request.UsageQuota,
+			Lifetime:       request.Lifetime,
+			Entitlements:   request.Entitlements.Compact(),
+		}
 
+		dbLicenses = append(dbLicenses, generation.newLicense(key, createdOn))
 		licenses = append(licenses, licenseData)
 	}
 
@@ -101,6 +116,9 @@ func GenerateLicense(ctx *gin.Context, db *gorm.DB) {
 		))
 		return
 	}
+	for i := range dbLicenses {
+		licenses[i].ID = dbLicenses[i].ID
+	}
 
 	// Invalidate the cache for the application's licenses
 	licensesCacheKey := "application:" + applicationID + ":licenses"
@@ -110,14 +128,238 @@ func GenerateLicense(ctx *gin.Context, db *gorm.DB) {
 	ctx.JSON(fasthttp.StatusCreated, gin.H{"licenses": licenses, "user": userInfo})
 }
 
+// licenseGeneration is what a LicenseRequest resolves to for an application before any key is generated
+type licenseGeneration struct {
+	application    *models.Application
+	request        *models.LicenseRequest
+	username       string
+	keyMask        *utils.KeyMask
+	checksumSecret string
+	keyVersion     int
+	duration       string
+	maxActivations int
+}
+
+// licenseGenerationError is a request the application cannot generate licenses for
+type licenseGenerationError struct {
+	message string
+	code    string
+	details interface{}
+}
+
+func (e *licenseGenerationError) Error() string {
+	return e.message
+}
+
+// newLicenseGeneration resolves the key mask, checksum and duration of a license request.
+// It returns a *licenseGenerationError when the request does not fit the application.
+func newLicenseGeneration(db *gorm.DB, application *models.Application, request *models.LicenseRequest, username string) (*licenseGeneration, error) {
+	generation := &licenseGeneration{
+		application:    application,
+		request:        request,
+		username:       username,
+		maxActivations: request.MaxActivations,
+	}
+	if generation.maxActivations == 0 {
+		generation.maxActivations = 1
+	}
+
+	generation.duration = utils.FormatDuration(request.LicenseDuration, request.LicenseExpiryUnit)
+	if request.Lifetime {
+		generation.duration = "Lifetime"
+	}
+
+	// An application that declares a key format generates every key with it
+	mask := request.LicenseMask
+	if declaredMask := application.Settings.KeyMask; declaredMask != "" {
+		if mask != "" && mask != declaredMask {
+			return nil, &licenseGenerationError{"License mask does not match the application's key format", "KEY_MASK_MISMATCH", map[string]string{"key_mask": declaredMask}}
+		}
+		mask = declaredMask
+	}
+	if mask == "" {
+		return nil, &licenseGenerationError{"License mask is required", "LICENSE_MASK_REQUIRED", nil}
+	}
+
+	keyMask, err := utils.ParseKeyMask(mask, application.Settings.KeyAlphabet)
+	if err != nil {
+		return nil, &licenseGenerationError{"Invalid license mask", "INVALID_LICENSE_MASK", err.Error()}
+	}
+	generation.keyMask = keyMask
+
+	entropy := keyMask.Entropy()
+	if requiredEntropy := utils.RequiredKeyEntropy(request.LicenseAmount); entropy < requiredEntropy {
+		return nil, &licenseGenerationError{
+			"License mask is too short for the requested amount of licenses",
+			"INSUFFICIENT_KEY_ENTROPY",
+			map[string]int{"entropy_bits": int(math.Floor(entropy)), "required_bits": int(math.Ceil(requiredEntropy))},
+		}
+	}
+
+	if err := application.Settings.CheckEntitlements(request.Entitlements); err != nil {
+		return nil, &licenseGenerationError{"Invalid entitlements", "INVALID_ENTITLEMENTS", err.Error()}
+	}
+
+	if application.Settings.KeyChecksum {
+		if err := ensureChecksumSecret(db, application); err != nil {
+			return nil, err
+		}
+		generation.keyVersion = utils.KeyChecksumVersion
+		generation.checksumSecret = application.ChecksumSecret
+	}
+
+	return generation, nil
+}
+
+// writeLicenseGenerationError writes the response for an error of newLicenseGeneration
+func writeLicenseGenerationError(ctx *gin.Context, err error) {
+	var generationErr *licenseGenerationError
+	if errors.As(err, &generationErr) {
+		ctx.JSON(fasthttp.StatusBadRequest, utils.NewErrorResponse(
+			fasthttp.StatusBadRequest,
+			generationErr.message,
+			generationErr.code,
+			generationErr.details,
+		))
+		return
+	}
+
+	ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+		fasthttp.StatusInternalServerError,
+		"Failed to generate checksum secret",
+		"CHECKSUM_SECRET_ERROR",
+		nil,
+	))
+}
+
+// newLicense builds an unused license with the given key
+func (generation *licenseGeneration) newLicense(key string, createdOn time.Time) models.License {
+	return models.License{
+		UserID:         generation.application.UserID,
+		ApplicationID:  generation.application.ApplicationID,
+		KeyHash:        utils.HashLicenseKey(key),
+		KeyHint:        utils.LicenseKeyHint(key),
+		KeyVersion:     generation.keyVersion,
+		Note:           generation.request.LicenseNote,
+		CreatedOn:      &createdOn,
+		Duration:       generation.duration,
+		GeneratedBy:    generation.username,
+		Status:         models.LicenseNotUsed,
+		IP:             "N/A",
+		HWID:           "N/A",
+		MaxActivations: generation.maxActivations,
+		MaxSessions:    generation.request.MaxSessions,
+		UsageQuota:     generation.request.UsageQuota,
+		Lifetime:       generation.request.Lifetime,
+		Entitlements:   generation.request.Entitlements.Compact(),
+	}
+}
+
+// maxKeyCollisions bounds how many colliding keys are regenerated before giving up on a batch
+const maxKeyCollisions = 10
+
+var errTooManyKeyCollisions = errors.New("too many license key collisions")
+
+// generateUniqueKeys generates keys that are unique within the batch and among the application's licenses,
+// regenerating any key that collides. Keys get a checksum segment when a checksum secret is given.
+func generateUniqueKeys(db *gorm.DB, applicationID string, prefix string, keyMask *utils.KeyMask, checksumSecret string, amount int) ([]string, error) {
+	keys := make([]string, 0, amount)
+	seen := make(map[string]bool, amount)
+	collisions := 0
+
+	for len(keys) < amount {
+		var candidates []string
+		for len(keys)+len(candidates) < amount {
+			key, err := keyMask.Generate(prefix)
+			if err != nil {
+				return nil, err
+			}
+			if checksumSecret != "" {
+				key = utils.AppendKeyChecksum(checksumSecret, key)
+			}
+			if seen[key] {
+				if collisions++; collisions > maxKeyCollisions {
+					return nil, errTooManyKeyCollisions
+				}
+				continue
+			}
+			seen[key] = true
+			candidates = append(candidates, key)
+		}
+
+		candidateHashes := make([]string, len(candidates))
+		for i, key := range candidates {
+			candidateHashes[i] = utils.HashLicenseKey(key)
+		}
+
+		// Deleted licenses still hold their key hash in the unique index
+		var existing []string
+		if err := db.Unscoped().Model(&models.License{}).Where("application_id = ? AND key_hash IN ?", applicationID, candidateHashes).Pluck("key_hash", &existing).Error; err != nil {
+			return nil, err
+		}
+		taken := make(map[string]bool, len(existing))
+		for _, keyHash := range existing {
+			taken[keyHash] = true
+		}
+
+		for i, key := range candidates {
+			if !taken[candidateHashes[i]] {
+				keys = append(keys, key)
+			} else if collisions++; collisions > maxKeyCollisions {
+				return nil, errTooManyKeyCollisions
+			}
+		}
+	}
+
+	return keys, nil
+}
+
+// whereLicense scopes a query to the license with the given identifier,
+// which is either the license's numeric ID or its key. Keys always contain a dash.
+func whereLicense(identifier string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if id, err := strconv.ParseUint(identifier, 10, 64); err == nil {
+			return db.Where("id = ?", id)
+		}
+		return db.Where("key_hash = ?", utils.HashLicenseKey(identifier))
+	}
+}
+
+// whereLicenses scopes a query to the licenses with the given identifiers, see whereLicense
+func whereLicenses(identifiers []string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		ids := []uint64{}
+		keyHashes := []string{}
+		for _, identifier := range identifiers {
+			if id, err := strconv.ParseUint(identifier, 10, 64); err == nil {
+				ids = append(ids, id)
+			} else {
+				keyHashes = append(keyHashes, utils.HashLicenseKey(identifier))
+			}
+		}
+		return db.Where("(id IN ? OR key_hash IN ?)", ids, keyHashes)
+	}
+}
+
+// identifiesLicense reports whether an identifier accepted by whereLicense refers to the license
+func identifiesLicense(license *models.License, identifier string) bool {
+	if id, err := strconv.ParseUint(identifier, 10, 64); err == nil {
+		return uint64(license.ID) == id
+	}
+	return utils.HashLicenseKey(identifier) == license.KeyHash
+}
+
 // RedeemLicense handles the redemption of a license using a license key and HWID.
 // @Summary Redeem a license
 // @Tags Licenses
-// @Description Redeem a license using license key and HWID for a specific application
+// @Description Redeem a license using license key and HWID for a specific application, following the application's HWID lock, IP lock, expiry start, grace period and client version settings; the returned token is signed with the application's key and carries the request nonce
 // @Accept json
 // @Produce json
+// @Param X-Signature header string false "HMAC-SHA256 request signature, required when the application requires signed requests"
+// @Param X-Signature-Timestamp header string false "Unix timestamp the request was signed at"
 // @Param application_id path string true "Application ID"
 // @Param request body models.RedeemLicenseRequest true "License redemption data"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
 // @Success 200 {object} map[string]string "OK"
 // @Failure 400 {object} map[string]string "Bad Request"
 // @Failure 401 {object} map[string]string "Unauthorized"
@@ -125,6 +367,7 @@ func GenerateLicense(ctx *gin.Context, db *gorm.DB) {
 // @Failure 404 {object} map[string]string "Not Found"
 // @Failure 409 {object} map[string]string "Conflict"
 // @Failure 410 {object} map[string]string "Gone"
+// @Failure 426 {object} map[string]string "Upgrade Required"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /api/v1/public/applications/{application_id}/redeem-license [post]
 func RedeemLicense(ctx *gin.Context, db *gorm.DB) {
@@ -142,8 +385,58 @@ func RedeemLicense(ctx *gin.Context, db *gorm.DB) {
 	request := ctx.MustGet("request").(*models.RedeemLicenseRequest)
 	applicationID := ctx.Param("application_id")
 
+	application, ok := findPublicApplication(ctx, db, applicationID)
+	if !ok {
+		return
+	}
+	settings := application.Settings
+	clientIP := utils.GetClientIP(ctx)
+
+	if !checkBlacklist(ctx, db, applicationID, request.HWID, clientIP) {
+		return
+	}
+
+	if !settings.ClientVersionAllowed(request.ClientVersion) {
+		ctx.JSON(fasthttp.StatusUpgradeRequired, utils.NewErrorResponse(
+			fasthttp.StatusUpgradeRequired,
+			"Client version is not allowed to redeem licenses",
+			"CLIENT_VERSION_NOT_ALLOWED",
+			map[string][]string{"allowed_client_versions": settings.AllowedClientVersions},
+		))
+		return
+	}
+
+	// Keys that cannot have been generated for this application are rejected without a lookup
+	keyBody, hasChecksum := request.Key, false
+	if application.ChecksumSecret != "" {
+		if body, ok := utils.VerifyKeyChecksum(application.ChecksumSecret, request.Key); ok {
+			keyBody, hasChecksum = body, true
+		}
+	}
+	if application.Settings.RequireKeyChecksum && !hasChecksum {
+		ctx.JSON(fasthttp.StatusBadRequest, utils.NewErrorResponse(
+			fasthttp.StatusBadRequest,
+			"License key checksum is missing or invalid",
+			"INVALID_KEY_CHECKSUM",
+			nil,
+		))
+		return
+	}
+	if declaredMask := application.Settings.KeyMask; declaredMask != "" {
+		keyMask, err := utils.ParseKeyMask(declaredMask, application.Settings.KeyAlphabet)
+		if err == nil && !keyMask.Matches(keyBody) {
+			ctx.JSON(fasthttp.StatusBadRequest, utils.NewErrorResponse(
+				fasthttp.StatusBadRequest,
+				"License key does not match the application's key format",
+				"INVALID_KEY_FORMAT",
+				nil,
+			))
+			return
+		}
+	}
+
 	var license models.License
-	if err := db.Where("key = ? AND application_id = ?", request.Key, applicationID).First(&license).Error; err != nil {
+	if err := db.Where("key_hash = ? AND application_id = ?", utils.HashLicenseKey(request.Key), applicationID).First(&license).Error; err != nil {
 		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
 			fasthttp.StatusNotFound,
 			"License not found",
@@ -153,19 +446,91 @@ func RedeemLicense(ctx *gin.Context, db *gorm.DB) {
 		return
 	}
 
-	if license.Status == "Used" {
-		expiresOn, err := time.Parse("2006-01-02 @ 03:04 PM", license.ExpiresOn)
+	if !liftExpiredBan(ctx, db, &license) {
+		return
+	}
+
+	if license.Status == models.LicenseActive {
+		if !checkLicenseExpiry(ctx, db, application, &license) {
+			return
+		}
+	} else if license.Status == models.LicenseExpired {
+		ctx.JSON(fasthttp.StatusGone, utils.NewErrorResponse(
+			fasthttp.StatusGone,
+			"License expired",
+			"LICENSE_EXPIRED",
+			nil,
+		))
+		return
+	} else if license.Status == models.LicenseBanned {
+		ctx.JSON(fasthttp.StatusForbidden, utils.NewErrorResponse(
+			fasthttp.StatusForbidden,
+			"License banned",
+			"LICENSE_BANNED",
+			nil,
+		))
+		return
+	} else if license.Status == models.LicenseFrozen {
+		ctx.JSON(fasthttp.StatusForbidden, utils.NewErrorResponse(
+			fasthttp.StatusForbidden,
+			"License frozen",
+			"LICENSE_FROZEN",
+			nil,
+		))
+		return
+	} else if license.Status == models.LicenseRevoked {
+		ctx.JSON(fasthttp.StatusForbidden, utils.NewErrorResponse(
+			fasthttp.StatusForbidden,
+			"License revoked",
+			"LICENSE_REVOKED",
+			nil,
+		))
+		return
+	}
+
+	now := time.Now().UTC()
+
+	if !checkLicenseNetwork(ctx, application, &license, clientIP) {
+		return
+	}
+
+	// A license is locked to the IP it was first redeemed from, widened to its subnet when the application sets
+	// prefix lengths. Licenses redeemed before the first IP was recorded are locked to the last one they used.
+	lockedIP := license.FirstIP
+	if lockedIP == "" {
+		lockedIP = license.IP
+	}
+	ipv4Bits, ipv6Bits := settings.IPLockPrefixLengths()
+	if settings.IPLock && license.Status != models.LicenseNotUsed && !utils.SameSubnet(lockedIP, clientIP, ipv4Bits, ipv6Bits) {
+		ctx.JSON(fasthttp.StatusForbidden, utils.NewErrorResponse(
+			fasthttp.StatusForbidden,
+			"IP mismatch for used license",
+			"IP_MISMATCH",
+			nil,
+		))
+		return
+	}
+
+	// Only the first redemption activates the license; returning clients keep their original activation and expiry
+	var expiresOn *time.Time
+	if license.Status == models.LicenseNotUsed && !license.Lifetime {
+		expiryStart := now
+		if settings.ExpiryStartsAtCreation() && license.CreatedOn != nil {
+			// Time spent frozen does not count
+			expiryStart = license.CreatedOn.Add(time.Duration(license.FrozenSeconds) * time.Second)
+		}
+		expiry, err := utils.AddDurationText(expiryStart, license.Duration)
 		if err != nil {
 			ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
 				fasthttp.StatusInternalServerError,
-				"Failed to parse expiry date",
-				"PARSE_ERROR",
+				"Invalid license duration",
+				"INVALID_DURATION",
 				nil,
 			))
 			return
 		}
-
-		if time.Now().After(expiresOn) {
+		// Unused licenses of applications counting from creation can run out before they are redeemed
+		if now.After(expiry.Add(settings.GracePeriod())) {
 			ctx.JSON(fasthttp.StatusGone, utils.NewErrorResponse(
 				fasthttp.StatusGone,
 				"License expired",
@@ -174,8 +539,37 @@ func RedeemLicense(ctx *gin.Context, db *gorm.DB) {
 			))
 			return
 		}
+		expiresOn = &expiry
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := activateMachine(tx, &license, request.HWID, clientIP, now, !settings.HWIDLockDisabled); err != nil {
+			return err
+		}
+
+		from := license.Status
+		if license.Status == models.LicenseNotUsed {
+			if err := license.Transition(models.LicenseActive); err != nil {
+				return err
+			}
+			license.UsedOn = &now
+			license.HWID = request.HWID
+			license.ExpiresOn = expiresOn
+			license.FirstIP = clientIP
+		} else if license.FirstIP == "" {
+			license.FirstIP = lockedIP
+		}
+		license.IP = clientIP
+		license.LastSeenOn = &now
 
-		if license.HWID != request.HWID {
+		// Only the redemption columns are written so usage consumed in the meantime is kept
+		return saveLicenseTransition(tx, &license, from, "UsedOn", "HWID", "ExpiresOn", "IP", "FirstIP", "LastSeenOn")
+	})
+	if errors.Is(err, errLicenseStatusChanged) {
+		writeLicenseStatusChanged(ctx)
+		return
+	} else if err == errActivationLimitReached {
+		if license.MaxActivations <= 1 {
 			ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
 				fasthttp.StatusConflict,
 				"HWID mismatch for used license",
@@ -184,27 +578,387 @@ func RedeemLicense(ctx *gin.Context, db *gorm.DB) {
 			))
 			return
 		}
-	} else if license.Status == "Banned" {
+		ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+			fasthttp.StatusConflict,
+			"License activation limit reached",
+			"ACTIVATION_LIMIT_REACHED",
+			map[string]int{"max_activations": license.MaxActivations},
+		))
+		return
+	} else if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to update license",
+			"UPDATE_FAILED",
+			nil,
+		))
+		return
+	}
+
+	// Invalidate the cache for the application's licenses
+	licensesCacheKey := "application:" + applicationID + ":licenses"
+	redisClient.Del(ctx, licensesCacheKey)
+	log.Printf("Cache invalidated for application %s licenses", applicationID)
+
+	token, err := signLicenseToken(db, application, &license, request.Key, request.HWID, request.Nonce)
+	if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to sign license token",
+			"SIGNING_FAILED",
+			nil,
+		))
+		return
+	}
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{"message": "Successfully logged in", "expires_on": formatExpiry(utils.NewTimeFormatter(ctx), &license), "entitlements": license.Entitlements, "token": token})
+}
+
+// formatExpiry renders the expiry of a license, using the placeholders of the legacy format when it has none
+func formatExpiry(timeFormatter utils.TimeFormatter, license *models.License) *string {
+	if license.Lifetime && license.UsedOn != nil {
+		return timeFormatter.FormatOr(license.ExpiresOn, "Never")
+	}
+	return timeFormatter.FormatOr(license.ExpiresOn, "N/A")
+}
+
+// signLicenseToken issues a compact JWS that clients can verify offline with the application's public key.
+// The client's nonce is included so a recorded response cannot be played back to it.
+func signLicenseToken(db *gorm.DB, application *models.Application, license *models.License, key string, hwid string, nonce string) (string, error) {
+	claims := models.LicenseTokenClaims{
+		Key:           key,
+		ApplicationID: license.ApplicationID,
+		HWID:          hwid,
+		Status:        license.Status,
+		Lifetime:      license.Lifetime,
+		Entitlements:  license.Entitlements,
+		Nonce:         nonce,
+		IssuedAt:      time.Now().Unix(),
+	}
+	if license.ExpiresOn != nil {
+		claims.ExpiresOn = license.ExpiresOn.UTC().Format(time.RFC3339)
+		claims.Expiry = license.ExpiresOn.Unix()
+	}
+
+	return signResponseClaims(db, application, claims)
+}
+
+// checkLicenseExpiry writes an error response and returns false when a redeemed license has expired
+// and the application's grace period has passed, marking the license as expired the first time this is noticed
+func checkLicenseExpiry(ctx *gin.Context, db *gorm.DB, application *models.Application, license *models.License) bool {
+	if license.Lifetime {
+		return true
+	}
+
+	if license.ExpiresOn == nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"License has no expiry date",
+			"MISSING_EXPIRY",
+			nil,
+		))
+		return false
+	}
+
+	if time.Now().After(license.ExpiresOn.Add(application.Settings.GracePeriod())) {
+		if err := expireLicense(ctx, db, license); err != nil {
+			log.Printf("Failed to mark license %d as expired: %v", license.ID, err)
+		}
+		ctx.JSON(fasthttp.StatusGone, utils.NewErrorResponse(
+			fasthttp.StatusGone,
+			"License expired",
+			"LICENSE_EXPIRED",
+			nil,
+		))
+		return false
+	}
+
+	return true
+}
+
+// checkLicenseUsable writes an error response and returns false unless the license has been redeemed
+// and is neither expired, banned, frozen nor revoked
+func checkLicenseUsable(ctx *gin.Context, db *gorm.DB, application *models.Application, license *models.License) bool {
+	if !checkLicenseNetwork(ctx, application, license, utils.GetClientIP(ctx)) {
+		return false
+	}
+
+	if !liftExpiredBan(ctx, db, license) {
+		return false
+	}
+
+	if license.Status == models.LicenseExpired {
+		ctx.JSON(fasthttp.StatusGone, utils.NewErrorResponse(
+			fasthttp.StatusGone,
+			"License expired",
+			"LICENSE_EXPIRED",
+			nil,
+		))
+		return false
+	} else if license.Status == models.LicenseBanned {
 		ctx.JSON(fasthttp.StatusForbidden, utils.NewErrorResponse(
 			fasthttp.StatusForbidden,
 			"License banned",
 			"LICENSE_BANNED",
 			nil,
 		))
+		return false
+	} else if license.Status == models.LicenseFrozen {
+		ctx.JSON(fasthttp.StatusForbidden, utils.NewErrorResponse(
+			fasthttp.StatusForbidden,
+			"License frozen",
+			"LICENSE_FROZEN",
+			nil,
+		))
+		return false
+	} else if license.Status == models.LicenseRevoked {
+		ctx.JSON(fasthttp.StatusForbidden, utils.NewErrorResponse(
+			fasthttp.StatusForbidden,
+			"License revoked",
+			"LICENSE_REVOKED",
+			nil,
+		))
+		return false
+	} else if license.Status != models.LicenseActive {
+		ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+			fasthttp.StatusConflict,
+			"License has not been redeemed",
+			"LICENSE_NOT_ACTIVE",
+			nil,
+		))
+		return false
+	}
+
+	return checkLicenseExpiry(ctx, db, application, license)
+}
+
+// checkLicenseNetwork writes an error response and returns false when the IP is outside the networks
+// the application or the license is restricted to
+func checkLicenseNetwork(ctx *gin.Context, application *models.Application, license *models.License, ip string) bool {
+	applicationNetworks := application.Settings.AllowedNetworks
+	if (len(applicationNetworks) == 0 || utils.IPInNetworks(ip, applicationNetworks)) &&
+		(len(license.AllowedNetworks) == 0 || utils.IPInNetworks(ip, license.AllowedNetworks)) {
+		return true
+	}
+
+	ctx.JSON(fasthttp.StatusForbidden, utils.NewErrorResponse(
+		fasthttp.StatusForbidden,
+		"License cannot be used from this IP",
+		"IP_NOT_ALLOWED",
+		nil,
+	))
+	return false
+}
+
+// checkActiveLicense verifies that a license is activated on the given HWID, callers check it is usable first.
+// It writes the error response and returns nil when the license cannot be used.
+func checkActiveLicense(ctx *gin.Context, db *gorm.DB, license *models.License, hwid string) *models.Activation {
+	var activation models.Activation
+	if err := db.Where("license_id = ? AND hw_id = ?", license.ID, hwid).First(&activation).Error; err != nil {
+		ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+			fasthttp.StatusConflict,
+			"HWID mismatch for used license",
+			"HWID_MISMATCH",
+			nil,
+		))
+		return nil
+	}
+
+	return &activation
+}
+
+// ValidateLicense reports the state of a license without modifying it.
+// @Summary Validate a license
+// @Tags Licenses
+// @Description Check status, remaining time and HWID match of a license without redeeming or otherwise changing it; the returned token is signed with the application's key and carries the request nonce
+// @Accept json
+// @Produce json
+// @Param X-Signature header string false "HMAC-SHA256 request signature, required when the application requires signed requests"
+// @Param X-Signature-Timestamp header string false "Unix timestamp the request was signed at"
+// @Param application_id path string true "Application ID"
+// @Param request body models.ValidateLicenseRequest true "License validation data"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
+// @Success 200 {object} map[string]interface{} "OK"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Router /api/v1/public/applications/{application_id}/validate-license [post]
+func ValidateLicense(ctx *gin.Context, db *gorm.DB) {
+	request := ctx.MustGet("request").(*models.ValidateLicenseRequest)
+	applicationID := ctx.Param("application_id")
+
+	application, ok := findPublicApplication(ctx, db, applicationID)
+	if !ok {
+		return
+	}
+
+	if !checkBlacklist(ctx, db, applicationID, request.HWID, utils.GetClientIP(ctx)) {
+		return
+	}
+
+	var license models.License
+	if err := db.Where("key_hash = ? AND application_id = ?", utils.HashLicenseKey(request.Key), applicationID).First(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"License not found",
+			"LICENSE_NOT_FOUND",
+			nil,
+		))
 		return
 	}
 
-	clientIP := utils.GetClientIP(ctx)
-	currentTime := time.Now()
-	expiresOn := utils.CalculateExpiryDateFromText(license.Duration)
+	if !checkLicenseNetwork(ctx, application, &license, utils.GetClientIP(ctx)) {
+		return
+	}
+
+	// Validation never changes the license, status transitions are left to redemption, heartbeats and admins
+	status := license.Status
+	if banExpired(&license, time.Now()) {
+		status = statusAfterBan(&license)
+	}
+
+	var activations int64
+	if err := db.Model(&models.Activation{}).Where("license_id = ? AND hw_id = ?", license.ID, request.HWID).Count(&activations).Error; err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to retrieve activations",
+			"ACTIVATION_RETRIEVAL_FAILED",
+			nil,
+		))
+		return
+	}
+	hwidMatch := activations > 0
+	// Lifetime licenses never expire and report no remaining time
+	var remainingSeconds int64
+	expired, inGracePeriod := false, false
+	if license.ExpiresOn != nil {
+		remaining := time.Until(*license.ExpiresOn)
+		// The clock of a frozen license stopped when it was frozen
+		if license.Status == models.LicenseFrozen && license.FrozenOn != nil {
+			remaining = license.ExpiresOn.Sub(*license.FrozenOn)
+		}
+		expired = remaining <= -application.Settings.GracePeriod()
+		inGracePeriod = remaining <= 0 && !expired
+		if remaining > 0 {
+			remainingSeconds = int64(remaining.Seconds())
+		}
+	}
+
+	if expired && status == models.LicenseActive {
+		status = models.LicenseExpired
+	}
+
+	claims := models.LicenseValidationClaims{
+		Key:           request.Key,
+		ApplicationID: applicationID,
+		HWID:          request.HWID,
+		Status:        status,
+		Valid:         status == models.LicenseActive && hwidMatch && !expired,
+		HWIDMatch:     hwidMatch,
+		Expired:       expired,
+		InGracePeriod: inGracePeriod,
+		Lifetime:      license.Lifetime,
+		Nonce:         request.Nonce,
+		IssuedAt:      time.Now().Unix(),
+	}
+	if license.ExpiresOn != nil {
+		claims.ExpiresOn = license.ExpiresOn.UTC().Format(time.RFC3339)
+	}
+	token, err := signResponseClaims(db, application, claims)
+	if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to sign validation token",
+			"SIGNING_FAILED",
+			nil,
+		))
+		return
+	}
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{
+		"valid":             claims.Valid,
+		"status":            status,
+		"hwid_match":        hwidMatch,
+		"expired":           expired,
+		"in_grace_period":   inGracePeriod,
+		"expires_on":        formatExpiry(utils.NewTimeFormatter(ctx), &license),
+		"lifetime":          license.Lifetime,
+		"remaining_seconds": remainingSeconds,
+		"token":             token,
+	})
+}
+
+// signResponseClaims signs the claims of a public response with the application's private key
+func signResponseClaims(db *gorm.DB, application *models.Application, claims interface{}) (string, error) {
+	if err := ensureSigningKeys(db, application); err != nil {
+		return "", err
+	}
+	return utils.SignToken(application.PrivateKey, claims)
+}
 
-	license.UsedOn = currentTime.Format("2006-01-02 @ 03:04 PM")
-	license.Status = "Used"
-	license.IP = clientIP
-	license.HWID = request.HWID
-	license.ExpiresOn = expiresOn
+// LicenseHeartbeat records that an activated client is still running.
+// @Summary License heartbeat
+// @Tags Licenses
+// @Description Record the last-seen time of a redeemed license without re-triggering redemption
+// @Accept json
+// @Produce json
+// @Param X-Signature header string false "HMAC-SHA256 request signature, required when the application requires signed requests"
+// @Param X-Signature-Timestamp header string false "Unix timestamp the request was signed at"
+// @Param application_id path string true "Application ID"
+// @Param request body models.ValidateLicenseRequest true "License heartbeat data"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
+// @Success 200 {object} map[string]interface{} "OK"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 410 {object} map[string]string "Gone"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/public/applications/{application_id}/heartbeat [post]
+func LicenseHeartbeat(ctx *gin.Context, db *gorm.DB) {
+	request := ctx.MustGet("request").(*models.ValidateLicenseRequest)
+	applicationID := ctx.Param("application_id")
 
-	if err := db.Save(&license).Error; err != nil {
+	application, ok := findPublicApplication(ctx, db, applicationID)
+	if !ok {
+		return
+	}
+
+	if !checkBlacklist(ctx, db, applicationID, request.HWID, utils.GetClientIP(ctx)) {
+		return
+	}
+
+	var license models.License
+	if err := db.Where("key_hash = ? AND application_id = ?", utils.HashLicenseKey(request.Key), applicationID).First(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"License not found",
+			"LICENSE_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	if !checkLicenseUsable(ctx, db, application, &license) {
+		return
+	}
+
+	activation := checkActiveLicense(ctx, db, &license, request.HWID)
+	if activation == nil {
+		return
+	}
+
+	// The licenses cache is not invalidated here, so last seen may lag in GetData by the cache TTL
+	lastSeenOn := time.Now().UTC()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(activation).Update("last_seen_on", lastSeenOn).Error; err != nil {
+			return err
+		}
+		return tx.Model(&license).Update("last_seen_on", lastSeenOn).Error
+	})
+	if err != nil {
 		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
 			fasthttp.StatusInternalServerError,
 			"Failed to update license",
@@ -214,12 +968,12 @@ func RedeemLicense(ctx *gin.Context, db *gorm.DB) {
 		return
 	}
 
-	// Invalidate the cache for the application's licenses
-	licensesCacheKey := "application:" + applicationID + ":licenses"
-	redisClient.Del(ctx, licensesCacheKey)
-	log.Printf("Cache invalidated for application %s licenses", applicationID)
-
-	ctx.JSON(fasthttp.StatusOK, gin.H{"message": "Successfully logged in", "expires_on": license.ExpiresOn})
+	timeFormatter := utils.NewTimeFormatter(ctx)
+	ctx.JSON(fasthttp.StatusOK, gin.H{
+		"message":      "Heartbeat recorded",
+		"last_seen_on": timeFormatter.Format(&lastSeenOn),
+		"expires_on":   formatExpiry(timeFormatter, &license),
+	})
 }
 
 // DeleteLicense handles the deletion of a single license.
@@ -254,7 +1008,7 @@ func DeleteLicense(ctx *gin.Context, db *gorm.DB) {
 	userID := userInfo["sub"].(string)
 
 	var license models.License
-	if err := db.Where("key = ? AND application_id = ? AND user_id = ?", licenseID, applicationID, userID).First(&license).Error; err != nil {
+	if err := db.Scopes(whereLicense(licenseID)).Where("application_id = ? AND user_id = ?", applicationID, userID).First(&license).Error; err != nil {
 		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
 			fasthttp.StatusNotFound,
 			"License not found or does not belong to the user",
@@ -316,7 +1070,7 @@ func DeleteLicenses(ctx *gin.Context, db *gorm.DB) {
 	userID := userInfo["sub"].(string)
 
 	tx := db.Begin()
-	if err := tx.Where("key IN ? AND application_id = ? AND user_id = ?", request.Keys, applicationID, userID).Delete(&models.License{}).Error; err != nil {
+	if err := tx.Scopes(whereLicenses(request.Keys)).Where("application_id = ? AND user_id = ?", applicationID, userID).Delete(&models.License{}).Error; err != nil {
 		tx.Rollback()
 		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
 			fasthttp.StatusInternalServerError,
@@ -391,16 +1145,18 @@ func DeleteAllLicenses(ctx *gin.Context, db *gorm.DB) {
 // BanLicense handles banning a license.
 // @Summary Ban a license
 // @Tags Licenses
-// @Description Ban a license based on key, application_id, and token
+// @Description Ban a license based on key, application_id, and token, optionally with a reason and an expiry; the HWIDs and IP of the license are blacklisted too when the application enables auto_blacklist_on_ban
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer token" default(Bearer <token>)
 // @Param application_id path string true "Application ID"
 // @Param request body models.BanLicenseRequest true "Request with license key to ban"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
 // @Success 200 {object} map[string]string "OK"
 // @Failure 400 {object} map[string]string "Bad Request"
 // @Failure 401 {object} map[string]string "Unauthorized"
 // @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /api/v1/private/applications/{application_id}/licenses/{license_id}/ban [patch]
 func BanLicense(ctx *gin.Context, db *gorm.DB) {
@@ -420,9 +1176,21 @@ func BanLicense(ctx *gin.Context, db *gorm.DB) {
 
 	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
 	userID := userInfo["sub"].(string)
+	username := userInfo["preferred_username"].(string)
+
+	var application models.Application
+	if err := db.Where("application_id = ? AND user_id = ?", applicationID, userID).First(&application).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"Application not found or does not belong to the user",
+			"APPLICATION_NOT_FOUND",
+			nil,
+		))
+		return
+	}
 
 	var license models.License
-	if err := db.Where("key = ? AND application_id = ? AND user_id = ?", request.Key, applicationID, userID).First(&license).Error; err != nil {
+	if err := db.Scopes(whereLicense(request.Key)).Where("application_id = ? AND user_id = ?", applicationID, userID).First(&license).Error; err != nil {
 		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
 			fasthttp.StatusNotFound,
 			"License not found",
@@ -432,8 +1200,65 @@ func BanLicense(ctx *gin.Context, db *gorm.DB) {
 		return
 	}
 
-	license.Status = "Banned"
-	if err := db.Save(&license).Error; err != nil {
+	if license.Status == models.LicenseBanned {
+		ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+			fasthttp.StatusConflict,
+			"License is already banned",
+			"LICENSE_ALREADY_BANNED",
+			nil,
+		))
+		return
+	}
+
+	bannedOn := time.Now().UTC()
+	var bannedUntil *time.Time
+	if request.BanDuration > 0 {
+		until, err := utils.AddDuration(bannedOn, request.BanDuration, request.BanExpiryUnit)
+		if err != nil {
+			ctx.JSON(fasthttp.StatusBadRequest, utils.NewErrorResponse(
+				fasthttp.StatusBadRequest,
+				"Invalid ban duration",
+				"INVALID_BAN_DURATION",
+				nil,
+			))
+			return
+		}
+		bannedUntil = &until
+	}
+
+	ban := models.LicenseBan{
+		LicenseID:     license.ID,
+		ApplicationID: license.ApplicationID,
+		Reason:        request.Reason,
+		BannedBy:      username,
+		BannedOn:      bannedOn,
+		BannedUntil:   bannedUntil,
+	}
+
+	statusBeforeBan := license.Status
+	if !transitionLicense(ctx, &license, models.LicenseBanned) {
+		return
+	}
+	license.StatusBeforeBan = statusBeforeBan
+	license.BannedUntil = bannedUntil
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&ban).Error; err != nil {
+			return err
+		}
+		// The machines of a banned license cannot come back with a new key
+		if application.Settings.AutoBlacklistOnBan {
+			if err := blacklistLicenseMachines(tx, &license, request.Reason, username, bannedOn); err != nil {
+				return err
+			}
+		}
+		return saveLicenseTransition(tx, &license, statusBeforeBan, "StatusBeforeBan", "BannedUntil")
+	})
+	if err != nil {
+		if errors.Is(err, errLicenseStatusChanged) {
+			writeLicenseStatusChanged(ctx)
+			return
+		}
 		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
 			fasthttp.StatusInternalServerError,
 			"Failed to ban license",
@@ -446,9 +1271,9 @@ func BanLicense(ctx *gin.Context, db *gorm.DB) {
 	// Invalidate the cache for the application's licenses
 	licensesCacheKey := "application:" + applicationID + ":licenses"
 	redisClient.Del(ctx, licensesCacheKey)
-	log.Printf("Cache invalidated for application %s licenses after deletion", applicationID)
+	log.Printf("Cache invalidated for application %s licenses after ban", applicationID)
 
-	ctx.JSON(fasthttp.StatusOK, gin.H{"message": "License banned successfully"})
+	ctx.JSON(fasthttp.StatusOK, gin.H{"message": "License banned successfully", "banned_until": utils.NewTimeFormatter(ctx).FormatOr(bannedUntil, "")})
 }
 
 // GetData retrieves data based on the token provided.
@@ -457,6 +1282,7 @@ func BanLicense(ctx *gin.Context, db *gorm.DB) {
 // @Description Get data based on the token
 // @Produce json
 // @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
 // @Success 200 {object} map[string]interface{} "OK"
 // @Failure 401 {object} map[string]string "Unauthorized"
 // @Failure 500 {object} map[string]string "Internal Server Error"
@@ -509,6 +1335,7 @@ func GetData(ctx *gin.Context, db *gorm.DB) {
 		log.Printf("Cache miss: Retrieved applications from database and cached for user %s", userID)
 	}
 
+	timeFormatter := utils.NewTimeFormatter(ctx)
 	licensesByApp := make(map[string][]models.LicenseResponse)
 	var response []map[string]interface{}
 
@@ -529,7 +1356,14 @@ func GetData(ctx *gin.Context, db *gorm.DB) {
 
 		// If cache miss or error, query the database
 		if err != nil || licenses == nil {
-			if err := db.Where("application_id = ?", app.ApplicationID).Find(&licenses).Error; err != nil {
+			// Bans are lifted first so a license whose ban ran out after it expired ends up expired
+			if err := liftExpiredBans(db, &app); err != nil {
+				log.Printf("Failed to lift expired bans of application %s: %v", app.ApplicationID, err)
+			}
+			if err := expireDueLicenses(db, &app); err != nil {
+				log.Printf("Failed to expire licenses of application %s: %v", app.ApplicationID, err)
+			}
+			if err := db.Preload("Bans").Where("application_id = ?", app.ApplicationID).Find(&licenses).Error; err != nil {
 				ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
 					fasthttp.StatusInternalServerError,
 					"Failed to retrieve licenses",
@@ -545,17 +1379,43 @@ func GetData(ctx *gin.Context, db *gorm.DB) {
 		}
 
 		for _, license := range licenses {
+			bans := []models.LicenseBanResponse{}
+			for _, ban := range license.Bans {
+				bans = append(bans, models.LicenseBanResponse{
+					Reason:      ban.Reason,
+					BannedBy:    ban.BannedBy,
+					BannedOn:    timeFormatter.Format(&ban.BannedOn),
+					BannedUntil: timeFormatter.FormatOr(ban.BannedUntil, ""),
+					UnbannedBy:  ban.UnbannedBy,
+					UnbannedOn:  timeFormatter.FormatOr(ban.UnbannedOn, ""),
+				})
+			}
+
 			licenseResponse := models.LicenseResponse{
-				Key:         license.Key,
-				Note:        license.Note,
-				CreatedOn:   license.CreatedOn,
-				Duration:    license.Duration,
-				GeneratedBy: license.GeneratedBy,
-				UsedOn:      license.UsedOn,
-				ExpiresOn:   license.ExpiresOn,
-				Status:      license.Status,
-				IP:          license.IP,
-				HWID:        license.HWID,
+				ID:              license.ID,
+				KeyHint:         license.KeyHint,
+				KeyVersion:      license.KeyVersion,
+				Note:            license.Note,
+				CreatedOn:       timeFormatter.Format(license.CreatedOn),
+				Duration:        license.Duration,
+				GeneratedBy:     license.GeneratedBy,
+				UsedOn:          timeFormatter.FormatOr(license.UsedOn, "N/A"),
+				ExpiresOn:       formatExpiry(timeFormatter, &license),
+				LastSeenOn:      timeFormatter.FormatOr(license.LastSeenOn, "N/A"),
+				Status:          license.Status,
+				IP:              license.IP,
+				HWID:            license.HWID,
+				MaxActivations:  license.MaxActivations,
+				MaxSessions:     license.MaxSessions,
+				UsageQuota:      license.UsageQuota,
+				UsageCount:      license.UsageCount,
+				Lifetime:        license.Lifetime,
+				Trial:           license.Trial,
+				BannedUntil:     timeFormatter.Format(license.BannedUntil),
+				FrozenOn:        timeFormatter.Format(license.FrozenOn),
+				Bans:            bans,
+				Entitlements:    license.Entitlements,
+				AllowedNetworks: license.AllowedNetworks,
 			}
 			licensesByApp[license.ApplicationID] = append(licensesByApp[license.ApplicationID], licenseResponse)
 		}
@@ -563,6 +1423,7 @@ func GetData(ctx *gin.Context, db *gorm.DB) {
 		appData := map[string]interface{}{
 			"application_id": app.ApplicationID,
 			"app_name":       app.AppName,
+			"paused":         app.Paused,
 			"created_at":     app.CreatedAt,
 			"updated_at":     app.UpdatedAt,
 			"licenses":       licensesByApp[app.ApplicationID],
@@ -0,0 +1,411 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+	"gorm.io/gorm"
+)
+
+const (
+	// licenseJobChunkSize is the number of licenses created per transaction
+	licenseJobChunkSize = 1000
+	// licenseJobLease is how long a worker holds a job without committing a chunk before another worker may take it over
+	licenseJobLease = 2 * time.Minute
+	// licenseJobPollInterval is how often workers look for queued jobs
+	licenseJobPollInterval = 5 * time.Second
+	// licenseJobKeysRetention is how long the generated keys of a finished job can be downloaded
+	licenseJobKeysRetention = 24 * time.Hour
+)
+
+var errLicenseJobLeaseLost = errors.New("license job was taken over by another worker")
+
+// CreateLicenseJob queues the generation of a large batch of licenses.
+// @Summary Generate licenses in the background
+// @Tags Licenses
+// @Description Queue a job generating up to 100000 licenses; poll the job for progress and download the keys when it is done
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param request body models.LicenseJobRequest true "License generation data"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
+// @Success 202 {object} map[string]interface{} "Accepted"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/license-jobs [post]
+func CreateLicenseJob(ctx *gin.Context, db *gorm.DB) {
+	request := (*models.LicenseRequest)(ctx.MustGet("request").(*models.LicenseJobRequest))
+	applicationID := ctx.Param("application_id")
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+	username := userInfo["preferred_username"].(string)
+
+	var application models.Application
+	if err := db.Where("application_id = ? AND user_id = ?", applicationID, userID).First(&application).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"Application not found or does not belong to the user",
+			"APPLICATION_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	// Reject requests that cannot succeed before queueing them, the worker resolves them again when it runs
+	if _, err := newLicenseGeneration(db, &application, request, username); err != nil {
+		writeLicenseGenerationError(ctx, err)
+		return
+	}
+
+	job := models.LicenseJob{
+		JobID:         uuid.New().String(),
+		ApplicationID: applicationID,
+		UserID:        userID,
+		Username:      username,
+		Request:       *request,
+		Status:        models.LicenseJobQueued,
+		Total:         request.LicenseAmount,
+	}
+	if err := db.Create(&job).Error; err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to create license job",
+			"JOB_CREATION_FAILED",
+			nil,
+		))
+		return
+	}
+
+	ctx.JSON(fasthttp.StatusAccepted, gin.H{"job": licenseJobResponse(utils.NewTimeFormatter(ctx), &job)})
+}
+
+// GetLicenseJob reports the progress of a license generation job.
+// @Summary Get a license generation job
+// @Tags Licenses
+// @Description Get the status and progress of a background license generation job
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param job_id path string true "Job ID"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
+// @Success 200 {object} map[string]interface{} "OK"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Router /api/v1/private/applications/{application_id}/license-jobs/{job_id} [get]
+func GetLicenseJob(ctx *gin.Context, db *gorm.DB) {
+	job, ok := findLicenseJob(ctx, db)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{"job": licenseJobResponse(utils.NewTimeFormatter(ctx), job)})
+}
+
+// DownloadLicenseJobKeys returns the keys generated by a finished job as text, one key per line.
+// @Summary Download the keys of a license generation job
+// @Tags Licenses
+// @Description Download the keys generated by a finished job; keys are kept for 24 hours after the job finishes
+// @Produce plain
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param job_id path string true "Job ID"
+// @Success 200 {string} string "OK"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 410 {object} map[string]string "Gone"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/license-jobs/{job_id}/keys [get]
+func DownloadLicenseJobKeys(ctx *gin.Context, db *gorm.DB) {
+	job, ok := findLicenseJob(ctx, db)
+	if !ok {
+		return
+	}
+
+	if job.Status != models.LicenseJobCompleted && job.Status != models.LicenseJobFailed {
+		ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+			fasthttp.StatusConflict,
+			"License job has not finished",
+			"JOB_NOT_FINISHED",
+			map[string]models.LicenseJobStatus{"status": job.Status},
+		))
+		return
+	}
+
+	if job.KeysExpireOn == nil || time.Now().After(*job.KeysExpireOn) {
+		ctx.JSON(fasthttp.StatusGone, utils.NewErrorResponse(
+			fasthttp.StatusGone,
+			"The keys of this job are no longer available",
+			"JOB_KEYS_EXPIRED",
+			nil,
+		))
+		return
+	}
+
+	var chunks []models.LicenseJobChunk
+	if err := db.Where("license_job_id = ?", job.ID).Order("chunk").Find(&chunks).Error; err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to retrieve license keys",
+			"KEY_RETRIEVAL_FAILED",
+			nil,
+		))
+		return
+	}
+
+	var body strings.Builder
+	for _, chunk := range chunks {
+		keys, err := utils.OpenLicenseKeys(chunk.Keys)
+		if err != nil {
+			log.Printf("Failed to open keys of chunk %d of license job %s: %v", chunk.Chunk, job.JobID, err)
+			ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+				fasthttp.StatusInternalServerError,
+				"Failed to retrieve license keys",
+				"KEY_RETRIEVAL_FAILED",
+				nil,
+			))
+			return
+		}
+		for _, key := range keys {
+			body.WriteString(key)
+			body.WriteByte('\n')
+		}
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="licenses-%s.txt"`, job.JobID))
+	ctx.Data(fasthttp.StatusOK, "text/plain; charset=utf-8", []byte(body.String()))
+}
+
+// findLicenseJob loads the job in the path for the user, writing a not found response when there is none
+func findLicenseJob(ctx *gin.Context, db *gorm.DB) (*models.LicenseJob, bool) {
+	applicationID := ctx.Param("application_id")
+	jobID := ctx.Param("job_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	var job models.LicenseJob
+	if err := db.Where("job_id = ? AND application_id = ? AND user_id = ?", jobID, applicationID, userID).First(&job).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"License job not found or does not belong to the user",
+			"JOB_NOT_FOUND",
+			nil,
+		))
+		return nil, false
+	}
+
+	return &job, true
+}
+
+func licenseJobResponse(timeFormatter utils.TimeFormatter, job *models.LicenseJob) models.LicenseJobResponse {
+	return models.LicenseJobResponse{
+		JobID:        job.JobID,
+		Status:       job.Status,
+		Total:        job.Total,
+		Generated:    job.Generated,
+		Error:        job.Error,
+		CreatedOn:    timeFormatter.Format(&job.CreatedAt),
+		CompletedOn:  timeFormatter.Format(job.CompletedOn),
+		KeysExpireOn: timeFormatter.Format(job.KeysExpireOn),
+	}
+}
+
+// StartLicenseJobWorker runs queued license jobs in the background.
+// Every instance may run a worker; a job is leased to one worker at a time through its row in the database,
+// and a job whose worker stopped, for example on a restart, resumes after its last committed chunk once the lease passes.
+func StartLicenseJobWorker(db *gorm.DB, redisClient *redis.Client) {
+	hostname, _ := os.Hostname()
+	workerID := fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.New().String()[:8])
+
+	go func() {
+		ticker := time.NewTicker(licenseJobPollInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := purgeExpiredLicenseJobKeys(db); err != nil {
+				log.Printf("Failed to purge expired license job keys: %v", err)
+			}
+
+			for {
+				job, err := claimLicenseJob(db, workerID)
+				if err != nil {
+					log.Printf("Failed to claim license job: %v", err)
+					break
+				}
+				if job == nil {
+					break
+				}
+				runLicenseJob(db, redisClient, workerID, job)
+			}
+		}
+	}()
+}
+
+// claimLicenseJob leases the oldest job that is queued or whose worker's lease has passed.
+// The lease is taken with a conditional update, so only one worker wins a job.
+func claimLicenseJob(db *gorm.DB, workerID string) (*models.LicenseJob, error) {
+	now := time.Now().UTC()
+
+	var candidates []models.LicenseJob
+	err := db.Where("status IN ? AND (locked_until IS NULL OR locked_until < ?)", []models.LicenseJobStatus{models.LicenseJobQueued, models.LicenseJobRunning}, now).
+		Order("id").Limit(5).Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, candidate := range candidates {
+		result := db.Model(&models.LicenseJob{}).
+			Where("id = ? AND status IN ? AND (locked_until IS NULL OR locked_until < ?)", candidate.ID, []models.LicenseJobStatus{models.LicenseJobQueued, models.LicenseJobRunning}, now).
+			Updates(map[string]interface{}{"status": models.LicenseJobRunning, "locked_by": workerID, "locked_until": now.Add(licenseJobLease)})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			var job models.LicenseJob
+			if err := db.First(&job, candidate.ID).Error; err != nil {
+				return nil, err
+			}
+			return &job, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// runLicenseJob generates the remaining licenses of a leased job chunk by chunk.
+// Each chunk's licenses, sealed keys and progress are committed together, so a resumed job never repeats a chunk.
+func runLicenseJob(db *gorm.DB, redisClient *redis.Client, workerID string, job *models.LicenseJob) {
+	log.Printf("Running license job %s from %d of %d licenses", job.JobID, job.Generated, job.Total)
+
+	var application models.Application
+	if err := db.Where("application_id = ?", job.ApplicationID).First(&application).Error; err != nil {
+		finishLicenseJob(db, workerID, job, fmt.Errorf("application not found"))
+		return
+	}
+
+	generation, err := newLicenseGeneration(db, &application, &job.Request, job.Username)
+	if err != nil {
+		finishLicenseJob(db, workerID, job, err)
+		return
+	}
+
+	for job.Generated < job.Total {
+		amount := job.Total - job.Generated
+		if amount > licenseJobChunkSize {
+			amount = licenseJobChunkSize
+		}
+
+		if err := runLicenseJobChunk(db, workerID, job, generation, amount); err == errLicenseJobLeaseLost {
+			log.Printf("License job %s was taken over by another worker", job.JobID)
+			return
+		} else if err != nil {
+			finishLicenseJob(db, workerID, job, err)
+			return
+		}
+
+		// Invalidate the cache for the application's licenses
+		licensesCacheKey := "application:" + job.ApplicationID + ":licenses"
+		redisClient.Del(context.Background(), licensesCacheKey)
+		log.Printf("Cache invalidated for application %s licenses", job.ApplicationID)
+	}
+
+	finishLicenseJob(db, workerID, job, nil)
+}
+
+func runLicenseJobChunk(db *gorm.DB, workerID string, job *models.LicenseJob, generation *licenseGeneration, amount int) error {
+	keys, err := generateUniqueKeys(db, job.ApplicationID, job.Request.Prefix, generation.keyMask, generation.checksumSecret, amount)
+	if err != nil {
+		return err
+	}
+
+	sealedKeys, err := utils.SealLicenseKeys(keys)
+	if err != nil {
+		return err
+	}
+
+	createdOn := time.Now().UTC()
+	licenses := make([]models.License, 0, len(keys))
+	for _, key := range keys {
+		licenses = append(licenses, generation.newLicense(key, createdOn))
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Renewing the lease first makes a worker that lost its job roll back before writing anything
+		result := tx.Model(&models.LicenseJob{}).
+			Where("id = ? AND locked_by = ?", job.ID, workerID).
+			Updates(map[string]interface{}{"generated": gorm.Expr("generated + ?", amount), "locked_until": time.Now().UTC().Add(licenseJobLease)})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return errLicenseJobLeaseLost
+		}
+
+		if err := tx.CreateInBatches(&licenses, 100).Error; err != nil {
+			return err
+		}
+
+		chunk := models.LicenseJobChunk{
+			LicenseJobID: job.ID,
+			Chunk:        job.Generated / licenseJobChunkSize,
+			Keys:         sealedKeys,
+		}
+		if err := tx.Create(&chunk).Error; err != nil {
+			return err
+		}
+
+		job.Generated += amount
+		return nil
+	})
+}
+
+// finishLicenseJob marks a job completed, or failed when err is set, and releases its lease.
+// Keys of chunks committed before a failure stay downloadable.
+func finishLicenseJob(db *gorm.DB, workerID string, job *models.LicenseJob, err error) {
+	now := time.Now().UTC()
+	keysExpireOn := now.Add(licenseJobKeysRetention)
+	updates := map[string]interface{}{
+		"status":         models.LicenseJobCompleted,
+		"completed_on":   now,
+		"keys_expire_on": keysExpireOn,
+		"locked_by":      "",
+		"locked_until":   nil,
+	}
+	if err != nil {
+		message := err.Error()
+		if len(message) > 255 {
+			message = message[:255]
+		}
+		updates["status"] = models.LicenseJobFailed
+		updates["error"] = message
+		log.Printf("License job %s failed after %d of %d licenses: %v", job.JobID, job.Generated, job.Total, err)
+	} else {
+		log.Printf("License job %s completed with %d licenses", job.JobID, job.Generated)
+	}
+
+	if err := db.Model(&models.LicenseJob{}).Where("id = ? AND locked_by = ?", job.ID, workerID).Updates(updates).Error; err != nil {
+		log.Printf("Failed to update license job %s: %v", job.JobID, err)
+	}
+}
+
+// purgeExpiredLicenseJobKeys permanently deletes the sealed keys of jobs whose retention has passed
+func purgeExpiredLicenseJobKeys(db *gorm.DB) error {
+	expiredJobs := db.Model(&models.LicenseJob{}).Select("id").Where("keys_expire_on < ?", time.Now().UTC())
+	return db.Unscoped().Where("license_job_id IN (?)", expiredJobs).Delete(&models.LicenseJobChunk{}).Error
+}
@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/utils"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// unreachableRedis is a client for jobs whose cache invalidation is not under test
+func unreachableRedis() *redis.Client {
+	return redis.NewClient(&redis.Options{Addr: "127.0.0.1:0", MaxRetries: -1})
+}
+
+func queueTestLicenseJob(t *testing.T, db *gorm.DB, application *models.Application, total int) *models.LicenseJob {
+	t.Helper()
+
+	job := models.LicenseJob{
+		JobID:         uuid.New().String(),
+		ApplicationID: application.ApplicationID,
+		UserID:        application.UserID,
+		Username:      "tester",
+		Request: models.LicenseRequest{
+			LicenseAmount:     total,
+			LicenseMask:       "XXXXX-XXXXX-XXXXX",
+			LicenseDuration:   30,
+			LicenseExpiryUnit: "days",
+		},
+		Status: models.LicenseJobQueued,
+		Total:  total,
+	}
+	if err := db.Create(&job).Error; err != nil {
+		t.Fatalf("queue job: %v", err)
+	}
+	return &job
+}
+
+// checkCompletedLicenseJob verifies a job created every license once and committed one chunk per chunk size
+func checkCompletedLicenseJob(t *testing.T, db *gorm.DB, jobID uint) {
+	t.Helper()
+
+	var job models.LicenseJob
+	if err := db.Preload("Chunks").First(&job, jobID).Error; err != nil {
+		t.Fatalf("load job: %v", err)
+	}
+	if job.Status != models.LicenseJobCompleted {
+		t.Errorf("status = %q, want %q (error %q)", job.Status, models.LicenseJobCompleted, job.Error)
+	}
+	if job.Generated != job.Total {
+		t.Errorf("generated = %d, want %d", job.Generated, job.Total)
+	}
+	wantChunks := (job.Total + licenseJobChunkSize - 1) / licenseJobChunkSize
+	if len(job.Chunks) != wantChunks {
+		t.Errorf("got %d chunks, want %d", len(job.Chunks), wantChunks)
+	}
+
+	seen := make(map[string]bool, job.Total)
+	for _, chunk := range job.Chunks {
+		keys, err := utils.OpenLicenseKeys(chunk.Keys)
+		if err != nil {
+			t.Fatalf("open chunk %d: %v", chunk.Chunk, err)
+		}
+		for _, key := range keys {
+			if seen[key] {
+				t.Errorf("key %s was generated twice", key)
+			}
+			seen[key] = true
+		}
+	}
+	if len(seen) != job.Total {
+		t.Errorf("chunks hold %d keys, want %d", len(seen), job.Total)
+	}
+
+	var licenses int64
+	if err := db.Model(&models.License{}).Where("application_id = ?", job.ApplicationID).Distinct("key_hash").Count(&licenses).Error; err != nil {
+		t.Fatalf("count licenses: %v", err)
+	}
+	if licenses != int64(job.Total) {
+		t.Errorf("got %d licenses, want %d", licenses, job.Total)
+	}
+}
+
+func TestLicenseJobRunsOnceAcrossWorkers(t *testing.T) {
+	db := openTestDB(t)
+	application := createTestApplication(t, db)
+	job := queueTestLicenseJob(t, db, application, 2500)
+
+	redisClient := unreachableRedis()
+	var wg sync.WaitGroup
+	for _, workerID := range []string{"worker-a", "worker-b"} {
+		wg.Add(1)
+		go func(workerID string) {
+			defer wg.Done()
+			for {
+				claimed, err := claimLicenseJob(db, workerID)
+				if err != nil {
+					t.Errorf("%s: claim job: %v", workerID, err)
+					return
+				}
+				if claimed == nil {
+					return
+				}
+				runLicenseJob(db, redisClient, workerID, claimed)
+			}
+		}(workerID)
+	}
+	wg.Wait()
+
+	checkCompletedLicenseJob(t, db, job.ID)
+}
+
+func TestLicenseJobResumesAfterLeaseTakeover(t *testing.T) {
+	db := openTestDB(t)
+	application := createTestApplication(t, db)
+	queued := queueTestLicenseJob(t, db, application, 2500)
+
+	first, err := claimLicenseJob(db, "worker-a")
+	if err != nil || first == nil {
+		t.Fatalf("worker-a claim: job %v, error %v", first, err)
+	}
+	if again, err := claimLicenseJob(db, "worker-b"); err != nil || again != nil {
+		t.Fatalf("worker-b claimed a leased job: job %v, error %v", again, err)
+	}
+
+	generation, err := newLicenseGeneration(db, application, &first.Request, first.Username)
+	if err != nil {
+		t.Fatalf("resolve generation: %v", err)
+	}
+	if err := runLicenseJobChunk(db, "worker-a", first, generation, licenseJobChunkSize); err != nil {
+		t.Fatalf("worker-a chunk: %v", err)
+	}
+
+	// Worker a stops renewing its lease, as it would when its instance restarts
+	if err := db.Model(&models.LicenseJob{}).Where("id = ?", queued.ID).Update("locked_until", time.Now().UTC().Add(-time.Second)).Error; err != nil {
+		t.Fatalf("expire lease: %v", err)
+	}
+
+	second, err := claimLicenseJob(db, "worker-b")
+	if err != nil || second == nil {
+		t.Fatalf("worker-b takeover: job %v, error %v", second, err)
+	}
+	if second.Generated != licenseJobChunkSize {
+		t.Fatalf("worker-b resumes from %d, want %d", second.Generated, licenseJobChunkSize)
+	}
+
+	// The old worker cannot commit anything once its job was taken over
+	if err := runLicenseJobChunk(db, "worker-a", first, generation, licenseJobChunkSize); err != errLicenseJobLeaseLost {
+		t.Fatalf("worker-a chunk after takeover: %v, want errLicenseJobLeaseLost", err)
+	}
+
+	runLicenseJob(db, unreachableRedis(), "worker-b", second)
+
+	checkCompletedLicenseJob(t, db, queued.ID)
+}
+
+func TestPurgeExpiredLicenseJobKeys(t *testing.T) {
+	db := openTestDB(t)
+	application := createTestApplication(t, db)
+
+	now := time.Now().UTC()
+	expired := queueTestLicenseJob(t, db, application, 1)
+	retained := queueTestLicenseJob(t, db, application, 1)
+	for _, job := range []struct {
+		id           uint
+		keysExpireOn time.Time
+	}{
+		{expired.ID, now.Add(-time.Minute)},
+		{retained.ID, now.Add(time.Hour)},
+	} {
+		if err := db.Model(&models.LicenseJob{}).Where("id = ?", job.id).Updates(map[string]interface{}{"status": models.LicenseJobCompleted, "keys_expire_on": job.keysExpireOn}).Error; err != nil {
+			t.Fatalf("complete job: %v", err)
+		}
+		if err := db.Create(&models.LicenseJobChunk{LicenseJobID: job.id, Keys: "sealed"}).Error; err != nil {
+			t.Fatalf("create chunk: %v", err)
+		}
+	}
+
+	if err := purgeExpiredLicenseJobKeys(db); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+
+	for _, job := range []struct {
+		id   uint
+		want int64
+	}{
+		{expired.ID, 0},
+		{retained.ID, 1},
+	} {
+		var chunks int64
+		if err := db.Unscoped().Model(&models.LicenseJobChunk{}).Where("license_job_id = ?", job.id).Count(&chunks).Error; err != nil {
+			t.Fatalf("count chunks: %v", err)
+		}
+		if chunks != job.want {
+			t.Errorf("job %d has %d chunks, want %d", job.id, chunks, job.want)
+		}
+	}
+}
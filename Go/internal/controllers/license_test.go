@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// redeemTestLicense calls RedeemLicense as a client connecting from remoteIP and returns the response status
+func redeemTestLicense(db *gorm.DB, applicationID string, key string, hwid string, remoteIP string) int {
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/api/v1/public/applications/"+applicationID+"/redeem-license", nil)
+	ctx.Request.RemoteAddr = remoteIP + ":40000"
+	ctx.Params = gin.Params{{Key: "application_id", Value: applicationID}}
+	ctx.Set("redisClient", unreachableRedis())
+	ctx.Set("request", &models.RedeemLicenseRequest{Key: key, HWID: hwid})
+
+	RedeemLicense(ctx, db)
+	return recorder.Code
+}
+
+func TestRedeemLicenseIPLockKeepsFirstIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := openTestDB(t)
+	application := createTestApplication(t, db)
+
+	const key = "IP-LOCK-TEST-KEY"
+	now := time.Now().UTC()
+	license := models.License{
+		UserID:        application.UserID,
+		ApplicationID: application.ApplicationID,
+		KeyHash:       utils.HashLicenseKey(key),
+		KeyHint:       utils.LicenseKeyHint(key),
+		CreatedOn:     &now,
+		Duration:      utils.FormatDuration(30, "Days"),
+		Status:        models.LicenseNotUsed,
+	}
+	if err := db.Create(&license).Error; err != nil {
+		t.Fatalf("create license: %v", err)
+	}
+
+	if status := redeemTestLicense(db, application.ApplicationID, key, "hwid-1", "203.0.113.10"); status != http.StatusOK {
+		t.Fatalf("first redemption: status %d, want %d", status, http.StatusOK)
+	}
+	// Without the lock the license may move, which records the new IP as the last one used
+	if status := redeemTestLicense(db, application.ApplicationID, key, "hwid-1", "198.51.100.20"); status != http.StatusOK {
+		t.Fatalf("redemption from a second IP: status %d, want %d", status, http.StatusOK)
+	}
+
+	application.Settings.IPLock = true
+	if err := db.Model(application).Select("setting_ip_lock").Updates(application).Error; err != nil {
+		t.Fatalf("enable IP lock: %v", err)
+	}
+
+	if status := redeemTestLicense(db, application.ApplicationID, key, "hwid-1", "198.51.100.20"); status != http.StatusForbidden {
+		t.Errorf("redemption from the last IP: status %d, want %d", status, http.StatusForbidden)
+	}
+	if status := redeemTestLicense(db, application.ApplicationID, key, "hwid-1", "203.0.113.10"); status != http.StatusOK {
+		t.Errorf("redemption from the first IP: status %d, want %d", status, http.StatusOK)
+	}
+}
@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"os"
+	"testing"
+
+	"backend/internal/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestMain(m *testing.M) {
+	// The pepper is read once, so it has to be set before the first key is hashed
+	os.Setenv("LICENSE_KEY_PEPPER", "controllers-test-pepper-0123456789abcdef")
+	os.Exit(m.Run())
+}
+
+// openTestDB opens a migrated database in a file, so that concurrent connections share it
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(t.TempDir()+"/test.db?_busy_timeout=10000"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if _, err := models.Migrate(db); err != nil {
+		t.Fatalf("migrate database: %v", err)
+	}
+	return db
+}
+
+// createTestApplication stores an application with default settings
+func createTestApplication(t *testing.T, db *gorm.DB) *models.Application {
+	t.Helper()
+
+	application := models.Application{ApplicationID: "00000000-0000-0000-0000-000000000001", AppName: "Test", UserID: "user"}
+	if err := db.Create(&application).Error; err != nil {
+		t.Fatalf("create application: %v", err)
+	}
+	return &application
+}
@@ -42,11 +42,40 @@ func registerPrivateRoutes(api *gin.RouterGroup, db *gorm.DB) {
 	private.Use(middleware.KeycloakAuth()) // Use combined middleware for Keycloak auth and user info check
 	{
 		private.POST("/applications", middleware.JSONValidation(&models.CreateApplicationRequest{}), func(c *gin.Context) { CreateApplication(c, db) })
+		private.PATCH("/applications/:application_id", middleware.ParamValidation("application_id"), middleware.JSONValidation(&models.RenameApplicationRequest{}), func(c *gin.Context) { RenameApplication(c, db) })
+		private.PATCH("/applications/:application_id/pause", middleware.ParamValidation("application_id"), func(c *gin.Context) { PauseApplication(c, db) })
+		private.PATCH("/applications/:application_id/resume", middleware.ParamValidation("application_id"), func(c *gin.Context) { ResumeApplication(c, db) })
+		private.DELETE("/applications/:application_id", middleware.ParamValidation("application_id"), func(c *gin.Context) { DeleteApplication(c, db) })
 		private.POST("/applications/:application_id/licenses", middleware.ParamValidation("application_id"), middleware.JSONValidation(&models.LicenseRequest{}), func(c *gin.Context) { GenerateLicense(c, db) })
+		private.POST("/applications/:application_id/license-jobs", middleware.ParamValidation("application_id"), middleware.JSONValidation(&models.LicenseJobRequest{}), func(c *gin.Context) { CreateLicenseJob(c, db) })
+		private.GET("/applications/:application_id/license-jobs/:job_id", middleware.ParamValidation("application_id", "job_id"), func(c *gin.Context) { GetLicenseJob(c, db) })
+		private.GET("/applications/:application_id/license-jobs/:job_id/keys", middleware.ParamValidation("application_id", "job_id"), func(c *gin.Context) { DownloadLicenseJobKeys(c, db) })
 		private.DELETE("/applications/:application_id/licenses/:license_id", middleware.ParamValidation("application_id", "license_id"), func(c *gin.Context) { DeleteLicense(c, db) })
 		private.DELETE("/applications/:application_id/licenses", middleware.ParamValidation("application_id"), middleware.JSONValidation(&models.DeleteLicensesRequest{}), func(c *gin.Context) { DeleteLicenses(c, db) })
 		private.DELETE("/applications/:application_id/licenses-all", middleware.ParamValidation("application_id"), func(c *gin.Context) { DeleteAllLicenses(c, db) })
 		private.PATCH("/applications/:application_id/licenses/:license_id/ban", middleware.ParamValidation("application_id", "license_id"), middleware.JSONValidation(&models.BanLicenseRequest{}), func(c *gin.Context) { BanLicense(c, db) })
+		private.PATCH("/applications/:application_id/licenses/:license_id/unban", middleware.ParamValidation("application_id", "license_id"), func(c *gin.Context) { UnbanLicense(c, db) })
+		private.PATCH("/applications/:application_id/licenses/:license_id/freeze", middleware.ParamValidation("application_id", "license_id"), func(c *gin.Context) { FreezeLicense(c, db) })
+		private.PATCH("/applications/:application_id/licenses/:license_id/resume", middleware.ParamValidation("application_id", "license_id"), func(c *gin.Context) { ResumeLicense(c, db) })
+		private.PATCH("/applications/:application_id/licenses/:license_id/revoke", middleware.ParamValidation("application_id", "license_id"), func(c *gin.Context) { RevokeLicense(c, db) })
+		private.PATCH("/applications/:application_id/licenses/:license_id/entitlements", middleware.ParamValidation("application_id", "license_id"), middleware.JSONValidation(&models.UpdateLicenseEntitlementsRequest{}), func(c *gin.Context) { UpdateLicenseEntitlements(c, db) })
+		private.PATCH("/applications/:application_id/licenses/:license_id/allowed-networks", middleware.ParamValidation("application_id", "license_id"), middleware.JSONValidation(&models.UpdateLicenseNetworksRequest{}), func(c *gin.Context) { UpdateLicenseNetworks(c, db) })
+		private.PATCH("/applications/:application_id/licenses/:license_id/convert", middleware.ParamValidation("application_id", "license_id"), middleware.JSONValidation(&models.ConvertTrialRequest{}), func(c *gin.Context) { ConvertTrial(c, db) })
+		private.PATCH("/applications/:application_id/licenses/:license_id/usage", middleware.ParamValidation("application_id", "license_id"), middleware.JSONValidation(&models.UpdateLicenseUsageRequest{}), func(c *gin.Context) { UpdateLicenseUsage(c, db) })
+		private.PATCH("/applications/:application_id/licenses/:license_id/extend", middleware.ParamValidation("application_id", "license_id"), middleware.JSONValidation(&models.ExtendLicenseRequest{}), func(c *gin.Context) { ExtendLicense(c, db) })
+		private.PATCH("/applications/:application_id/licenses-extend", middleware.ParamValidation("application_id"), middleware.JSONValidation(&models.ExtendLicensesRequest{}), func(c *gin.Context) { ExtendLicenses(c, db) })
+		private.GET("/applications/:application_id/licenses/:license_id/activations", middleware.ParamValidation("application_id", "license_id"), func(c *gin.Context) { ListActivations(c, db) })
+		private.DELETE("/applications/:application_id/licenses/:license_id/activations/:activation_id", middleware.ParamValidation("application_id", "license_id", "activation_id"), func(c *gin.Context) { RevokeActivation(c, db) })
+		private.PATCH("/applications/:application_id/licenses/:license_id/reset-hwid", middleware.ParamValidation("application_id", "license_id"), func(c *gin.Context) { ResetLicenseHWID(c, db) })
+		private.GET("/applications/:application_id/licenses/:license_id/hwid-resets", middleware.ParamValidation("application_id", "license_id"), func(c *gin.Context) { ListHWIDResets(c, db) })
+		private.POST("/applications/:application_id/blacklist", middleware.ParamValidation("application_id"), middleware.JSONValidation(&models.BlacklistEntryRequest{}), func(c *gin.Context) { AddBlacklistEntry(c, db) })
+		private.GET("/applications/:application_id/blacklist", middleware.ParamValidation("application_id"), func(c *gin.Context) { ListBlacklist(c, db) })
+		private.DELETE("/applications/:application_id/blacklist/:entry_id", middleware.ParamValidation("application_id", "entry_id"), func(c *gin.Context) { RemoveBlacklistEntry(c, db) })
+		private.GET("/applications/:application_id/key-checksum", middleware.ParamValidation("application_id"), func(c *gin.Context) { GetKeyChecksumSecret(c, db) })
+		private.GET("/applications/:application_id/client-secret", middleware.ParamValidation("application_id"), func(c *gin.Context) { GetClientSecret(c, db) })
+		private.POST("/applications/:application_id/client-secret/rotate", middleware.ParamValidation("application_id"), middleware.JSONValidation(&models.RotateClientSecretRequest{}), func(c *gin.Context) { RotateClientSecret(c, db) })
+		private.GET("/applications/:application_id/settings", middleware.ParamValidation("application_id"), func(c *gin.Context) { GetApplicationSettings(c, db) })
+		private.PATCH("/applications/:application_id/settings", middleware.ParamValidation("application_id"), middleware.JSONValidation(&models.UpdateApplicationSettingsRequest{}), func(c *gin.Context) { UpdateApplicationSettings(c, db) })
 		private.GET("/applications/data", func(c *gin.Context) { GetData(c, db) })
 	}
 }
@@ -55,8 +84,17 @@ func registerPrivateRoutes(api *gin.RouterGroup, db *gorm.DB) {
 func registerPublicRoutes(api *gin.RouterGroup, db *gorm.DB) {
 	public := api.Group("/public")
 	{
-		public.POST("/applications/:application_id/redeem-license", middleware.ParamValidation("application_id"), middleware.JSONValidation(&models.RedeemLicenseRequest{}), func(c *gin.Context) {
+		public.POST("/applications/:application_id/redeem-license", middleware.ParamValidation("application_id"), middleware.ClientSignature(db), middleware.JSONValidation(&models.RedeemLicenseRequest{}), func(c *gin.Context) {
 			RedeemLicense(c, db)
 		})
+		public.POST("/applications/:application_id/validate-license", middleware.ParamValidation("application_id"), middleware.ClientSignature(db), middleware.JSONValidation(&models.ValidateLicenseRequest{}), func(c *gin.Context) { ValidateLicense(c, db) })
+		public.POST("/applications/:application_id/heartbeat", middleware.ParamValidation("application_id"), middleware.ClientSignature(db), middleware.JSONValidation(&models.ValidateLicenseRequest{}), func(c *gin.Context) { LicenseHeartbeat(c, db) })
+		public.POST("/applications/:application_id/trial", middleware.ParamValidation("application_id"), middleware.ClientSignature(db), middleware.JSONValidation(&models.TrialRequest{}), func(c *gin.Context) { RequestTrial(c, db) })
+		public.POST("/applications/:application_id/usage", middleware.ParamValidation("application_id"), middleware.ClientSignature(db), middleware.JSONValidation(&models.ConsumeUsageRequest{}), func(c *gin.Context) { ConsumeUsage(c, db) })
+		public.POST("/applications/:application_id/sessions/checkout", middleware.ParamValidation("application_id"), middleware.ClientSignature(db), middleware.JSONValidation(&models.ValidateLicenseRequest{}), func(c *gin.Context) { CheckoutSession(c, db) })
+		public.POST("/applications/:application_id/sessions/heartbeat", middleware.ParamValidation("application_id"), middleware.ClientSignature(db), middleware.JSONValidation(&models.SessionRequest{}), func(c *gin.Context) { RenewSession(c, db) })
+		public.POST("/applications/:application_id/sessions/checkin", middleware.ParamValidation("application_id"), middleware.ClientSignature(db), middleware.JSONValidation(&models.SessionRequest{}), func(c *gin.Context) { CheckinSession(c, db) })
+		public.POST("/applications/:application_id/reset-hwid", middleware.ParamValidation("application_id"), middleware.ClientSignature(db), middleware.JSONValidation(&models.ResetHWIDRequest{}), func(c *gin.Context) { ResetOwnHWID(c, db) })
+		public.GET("/applications/:application_id/public-key", middleware.ParamValidation("application_id"), func(c *gin.Context) { GetPublicKey(c, db) })
 	}
 }
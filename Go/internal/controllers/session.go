@@ -0,0 +1,296 @@
+package controllers
+
+import (
+	"strconv"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+	"gorm.io/gorm"
+)
+
+// defaultSessionTTL is the lifetime of a session lease for applications that do not set one
+const defaultSessionTTL = 5 * time.Minute
+
+// Sessions of a license are members of a sorted set scored by the unix milliseconds they expire at.
+// Both scripts drop expired sessions first, so a client that never checks in frees its slot once its lease passes.
+
+// checkoutSessionScript adds a session unless the license already has the maximum number of live sessions.
+// KEYS[1] is the set, ARGV is now, the session's expiry, its ID, the maximum (0 for unlimited) and the TTL, times in milliseconds.
+var checkoutSessionScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+local limit = tonumber(ARGV[4])
+if limit > 0 and redis.call('ZCARD', KEYS[1]) >= limit then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[3])
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+return 1
+`)
+
+// renewSessionScript extends a live session, returning 0 when it has expired or was checked in.
+// KEYS[1] is the set, ARGV is now, the session's new expiry, its ID and the TTL, times in milliseconds.
+var renewSessionScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+if not redis.call('ZSCORE', KEYS[1], ARGV[3]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], 'XX', ARGV[2], ARGV[3])
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return 1
+`)
+
+// CheckoutSession leases a session on a redeemed license.
+// @Summary Check out a session
+// @Tags Sessions
+// @Description Start a session on a license activated on the HWID; fails when the license's concurrent session limit is reached
+// @Accept json
+// @Produce json
+// @Param X-Signature header string false "HMAC-SHA256 request signature, required when the application requires signed requests"
+// @Param X-Signature-Timestamp header string false "Unix timestamp the request was signed at"
+// @Param application_id path string true "Application ID"
+// @Param request body models.ValidateLicenseRequest true "License key and HWID"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
+// @Success 201 {object} map[string]interface{} "Created"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 410 {object} map[string]string "Gone"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/public/applications/{application_id}/sessions/checkout [post]
+func CheckoutSession(ctx *gin.Context, db *gorm.DB) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	request := ctx.MustGet("request").(*models.ValidateLicenseRequest)
+	applicationID := ctx.Param("application_id")
+
+	application, license, ok := findSessionLicense(ctx, db, applicationID, request.Key)
+	if !ok {
+		return
+	}
+	if !checkBlacklist(ctx, db, applicationID, request.HWID, utils.GetClientIP(ctx)) {
+		return
+	}
+	if !checkLicenseUsable(ctx, db, application, license) || checkActiveLicense(ctx, db, license, request.HWID) == nil {
+		return
+	}
+
+	sessionID := uuid.New().String()
+	ttl := sessionTTL(application)
+	now := time.Now()
+	expiresOn := now.Add(ttl)
+	leased, err := checkoutSessionScript.Run(ctx, redisClient, []string{sessionsKey(license)},
+		now.UnixMilli(), expiresOn.UnixMilli(), sessionID, license.MaxSessions, ttl.Milliseconds(),
+	).Int()
+	if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to check out session",
+			"SESSION_CHECKOUT_FAILED",
+			nil,
+		))
+		return
+	}
+	if leased == 0 {
+		ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+			fasthttp.StatusConflict,
+			"License session limit reached",
+			"SESSION_LIMIT_REACHED",
+			map[string]int{"max_sessions": license.MaxSessions},
+		))
+		return
+	}
+
+	ctx.JSON(fasthttp.StatusCreated, gin.H{
+		"session_id":  sessionID,
+		"expires_on":  utils.NewTimeFormatter(ctx).Format(&expiresOn),
+		"ttl_seconds": int(ttl.Seconds()),
+	})
+}
+
+// RenewSession extends the lease of a session.
+// @Summary Renew a session
+// @Tags Sessions
+// @Description Extend a checked out session; sessions that are not renewed within their TTL expire
+// @Accept json
+// @Produce json
+// @Param X-Signature header string false "HMAC-SHA256 request signature, required when the application requires signed requests"
+// @Param X-Signature-Timestamp header string false "Unix timestamp the request was signed at"
+// @Param application_id path string true "Application ID"
+// @Param request body models.SessionRequest true "License key and session ID"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
+// @Success 200 {object} map[string]interface{} "OK"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 410 {object} map[string]string "Gone"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/public/applications/{application_id}/sessions/heartbeat [post]
+func RenewSession(ctx *gin.Context, db *gorm.DB) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	request := ctx.MustGet("request").(*models.SessionRequest)
+	applicationID := ctx.Param("application_id")
+
+	application, license, ok := findSessionLicense(ctx, db, applicationID, request.Key)
+	if !ok {
+		return
+	}
+
+	// A license that was banned, frozen or revoked, or a blacklisted IP, ends its sessions at their next heartbeat
+	if !checkBlacklist(ctx, db, applicationID, "", utils.GetClientIP(ctx)) || !checkLicenseUsable(ctx, db, application, license) {
+		redisClient.ZRem(ctx, sessionsKey(license), request.SessionID)
+		return
+	}
+
+	ttl := sessionTTL(application)
+	now := time.Now()
+	expiresOn := now.Add(ttl)
+	renewed, err := renewSessionScript.Run(ctx, redisClient, []string{sessionsKey(license)},
+		now.UnixMilli(), expiresOn.UnixMilli(), request.SessionID, ttl.Milliseconds(),
+	).Int()
+	if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to renew session",
+			"SESSION_RENEWAL_FAILED",
+			nil,
+		))
+		return
+	}
+	if renewed == 0 {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"Session not found or expired",
+			"SESSION_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{
+		"session_id":  request.SessionID,
+		"expires_on":  utils.NewTimeFormatter(ctx).Format(&expiresOn),
+		"ttl_seconds": int(ttl.Seconds()),
+	})
+}
+
+// CheckinSession ends a session and frees its slot.
+// @Summary Check in a session
+// @Tags Sessions
+// @Description End a checked out session so another machine can use the license
+// @Accept json
+// @Produce json
+// @Param X-Signature header string false "HMAC-SHA256 request signature, required when the application requires signed requests"
+// @Param X-Signature-Timestamp header string false "Unix timestamp the request was signed at"
+// @Param application_id path string true "Application ID"
+// @Param request body models.SessionRequest true "License key and session ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/public/applications/{application_id}/sessions/checkin [post]
+func CheckinSession(ctx *gin.Context, db *gorm.DB) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	request := ctx.MustGet("request").(*models.SessionRequest)
+	applicationID := ctx.Param("application_id")
+
+	_, license, ok := findSessionLicense(ctx, db, applicationID, request.Key)
+	if !ok {
+		return
+	}
+
+	removed, err := redisClient.ZRem(ctx, sessionsKey(license), request.SessionID).Result()
+	if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to check in session",
+			"SESSION_CHECKIN_FAILED",
+			nil,
+		))
+		return
+	}
+	if removed == 0 {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"Session not found or expired",
+			"SESSION_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	ctx.JSON(fasthttp.StatusNoContent, nil)
+}
+
+// findSessionLicense loads the license a session request is for together with its application.
+// It writes an error response and returns false when either does not exist or the application is paused.
+func findSessionLicense(ctx *gin.Context, db *gorm.DB, applicationID string, key string) (*models.Application, *models.License, bool) {
+	application, ok := findPublicApplication(ctx, db, applicationID)
+	if !ok {
+		return nil, nil, false
+	}
+
+	var license models.License
+	if err := db.Where("key_hash = ? AND application_id = ?", utils.HashLicenseKey(key), applicationID).First(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"License not found",
+			"LICENSE_NOT_FOUND",
+			nil,
+		))
+		return nil, nil, false
+	}
+
+	return application, &license, true
+}
+
+// sessionTTL returns the lifetime of a session lease of the application
+func sessionTTL(application *models.Application) time.Duration {
+	if application.Settings.SessionTTLSeconds > 0 {
+		return time.Duration(application.Settings.SessionTTLSeconds) * time.Second
+	}
+	return defaultSessionTTL
+}
+
+func sessionsKey(license *models.License) string {
+	return "license:" + strconv.FormatUint(uint64(license.ID), 10) + ":sessions"
+}
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/valyala/fasthttp"
+	"gorm.io/gorm"
+)
+
+// RevokeLicense permanently invalidates a license.
+// @Summary Revoke a license
+// @Tags Licenses
+// @Description Permanently invalidate a license; revoked licenses cannot be restored
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param license_id path string true "License ID"
+// @Success 200 {object} map[string]string "OK"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/licenses/{license_id}/revoke [patch]
+func RevokeLicense(ctx *gin.Context, db *gorm.DB) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	licenseID := ctx.Param("license_id")
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	var license models.License
+	if err := db.Scopes(whereLicense(licenseID)).Where("application_id = ? AND user_id = ?", applicationID, userID).First(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"License not found or does not belong to the user",
+			"LICENSE_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	from := license.Status
+	if !transitionLicense(ctx, &license, models.LicenseRevoked) {
+		return
+	}
+
+	if err := saveLicenseTransition(db, &license, from); err != nil {
+		if errors.Is(err, errLicenseStatusChanged) {
+			writeLicenseStatusChanged(ctx)
+			return
+		}
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to revoke license",
+			"REVOKE_FAILED",
+			nil,
+		))
+		return
+	}
+
+	// Invalidate the cache for the application's licenses
+	licensesCacheKey := "application:" + applicationID + ":licenses"
+	redisClient.Del(ctx, licensesCacheKey)
+	log.Printf("Cache invalidated for application %s licenses after revocation", applicationID)
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{"message": "License revoked successfully", "status": license.Status})
+}
+
+// transitionLicense moves a license to the given status without saving it.
+// It writes a conflict response and returns false when the license cannot move to that status.
+func transitionLicense(ctx *gin.Context, license *models.License, to models.LicenseStatus) bool {
+	err := license.Transition(to)
+	if err == nil {
+		return true
+	}
+
+	var transitionErr *models.StatusTransitionError
+	if errors.As(err, &transitionErr) {
+		ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+			fasthttp.StatusConflict,
+			"License cannot move from "+string(transitionErr.From)+" to "+string(transitionErr.To),
+			"INVALID_STATUS_TRANSITION",
+			map[string]models.LicenseStatus{"from": transitionErr.From, "to": transitionErr.To},
+		))
+		return false
+	}
+
+	ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+		fasthttp.StatusInternalServerError,
+		"Failed to update license status",
+		"UPDATE_FAILED",
+		nil,
+	))
+	return false
+}
+
+// errLicenseStatusChanged is returned when the stored status of a license is no longer the one a transition started from
+var errLicenseStatusChanged = errors.New("license status changed concurrently")
+
+// saveLicenseTransition writes the status of a license moved with License.Transition, together with the given columns.
+// The write only applies while the stored status is still the one the transition started from, so a concurrent
+// ban, revoke or expiry is never silently overwritten; errLicenseStatusChanged is returned instead.
+func saveLicenseTransition(db *gorm.DB, license *models.License, from models.LicenseStatus, columns ...string) error {
+	result := db.Model(license).Where("status = ?", from).Select(append([]string{"Status"}, columns...)).Updates(license)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errLicenseStatusChanged
+	}
+	return nil
+}
+
+// transitionLicenses moves every license matched by the query from one status to another in bulk.
+// It applies the same rules as License.Transition and only touches licenses still in the from status.
+func transitionLicenses(query *gorm.DB, from, to models.LicenseStatus) error {
+	if !from.CanTransition(to) {
+		return &models.StatusTransitionError{From: from, To: to}
+	}
+	return query.Model(&models.License{}).Where("status = ?", from).Update("status", to).Error
+}
+
+// writeLicenseStatusChanged writes the conflict response for errLicenseStatusChanged
+func writeLicenseStatusChanged(ctx *gin.Context) {
+	ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+		fasthttp.StatusConflict,
+		"License status was changed by another request, reload it and try again",
+		"LICENSE_STATUS_CHANGED",
+		nil,
+	))
+}
+
+// expireLicense marks an active license whose expiry has passed as expired
+func expireLicense(ctx *gin.Context, db *gorm.DB, license *models.License) error {
+	from := license.Status
+	if err := license.Transition(models.LicenseExpired); err != nil {
+		return err
+	}
+	if err := saveLicenseTransition(db, license, from); err != nil {
+		return err
+	}
+
+	if redisClient, ok := ctx.MustGet("redisClient").(*redis.Client); ok {
+		licensesCacheKey := "application:" + license.ApplicationID + ":licenses"
+		redisClient.Del(ctx, licensesCacheKey)
+		log.Printf("Cache invalidated for application %s licenses after expiry", license.ApplicationID)
+	}
+
+	return nil
+}
+
+// expireDueLicenses marks every active license of an application whose expiry and grace period have passed as expired.
+func expireDueLicenses(db *gorm.DB, application *models.Application) error {
+	due := db.Where("application_id = ? AND expires_on < ?", application.ApplicationID, time.Now().UTC().Add(-application.Settings.GracePeriod()))
+	return transitionLicenses(due, models.LicenseActive, models.LicenseExpired)
+}
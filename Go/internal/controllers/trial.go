@@ -0,0 +1,413 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/valyala/fasthttp"
+	"gorm.io/gorm"
+)
+
+// defaultTrialDurationDays is the length of a trial for applications that do not set one
+const defaultTrialDurationDays = 7
+
+// trialKeyMask is used for trial keys of applications that do not declare a key mask
+const trialKeyMask = "XXXXX-XXXXX-XXXXX-XXXXX"
+
+// RequestTrial issues a trial license bound to a HWID, or returns the one issued before.
+// @Summary Request a trial license
+// @Tags Licenses
+// @Description Issue a trial license activated on the HWID; every HWID gets one trial per application, even after the trial license is deleted
+// @Accept json
+// @Produce json
+// @Param X-Signature header string false "HMAC-SHA256 request signature, required when the application requires signed requests"
+// @Param X-Signature-Timestamp header string false "Unix timestamp the request was signed at"
+// @Param application_id path string true "Application ID"
+// @Param request body models.TrialRequest true "HWID of the machine"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
+// @Success 200 {object} map[string]interface{} "OK"
+// @Success 201 {object} map[string]interface{} "Created"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 410 {object} map[string]string "Gone"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/public/applications/{application_id}/trial [post]
+func RequestTrial(ctx *gin.Context, db *gorm.DB) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	request := ctx.MustGet("request").(*models.TrialRequest)
+	applicationID := ctx.Param("application_id")
+
+	application, ok := findPublicApplication(ctx, db, applicationID)
+	if !ok {
+		return
+	}
+
+	if !checkBlacklist(ctx, db, applicationID, request.HWID, utils.GetClientIP(ctx)) {
+		return
+	}
+
+	if !application.Settings.TrialEnabled {
+		ctx.JSON(fasthttp.StatusForbidden, utils.NewErrorResponse(
+			fasthttp.StatusForbidden,
+			"Trials are not offered for this application",
+			"TRIALS_DISABLED",
+			nil,
+		))
+		return
+	}
+
+	key, err := trialKey(db, application, request.HWID)
+	if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to generate trial key",
+			"KEY_GENERATION_FAILED",
+			nil,
+		))
+		return
+	}
+
+	var claim models.TrialClaim
+	err = db.Where("application_id = ? AND hw_id = ?", applicationID, request.HWID).First(&claim).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		license, err := issueTrial(db, application, key, request.HWID, utils.GetClientIP(ctx))
+		if err == nil {
+			// Invalidate the cache for the application's licenses
+			licensesCacheKey := "application:" + applicationID + ":licenses"
+			redisClient.Del(ctx, licensesCacheKey)
+			log.Printf("Cache invalidated for application %s licenses after issuing a trial", applicationID)
+
+			ctx.JSON(fasthttp.StatusCreated, gin.H{
+				"message":    "Trial issued",
+				"key":        key,
+				"expires_on": formatExpiry(utils.NewTimeFormatter(ctx), license),
+				"status":     license.Status,
+			})
+			return
+		}
+
+		// A concurrent request for the same HWID may have claimed the trial first
+		err = db.Where("application_id = ? AND hw_id = ?", applicationID, request.HWID).First(&claim).Error
+	}
+	if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to issue trial",
+			"TRIAL_FAILED",
+			nil,
+		))
+		return
+	}
+
+	var license models.License
+	if err := db.Where("id = ?", claim.LicenseID).First(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+			fasthttp.StatusConflict,
+			"This machine has already used its trial",
+			"TRIAL_ALREADY_USED",
+			nil,
+		))
+		return
+	}
+
+	if !license.Trial {
+		ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+			fasthttp.StatusConflict,
+			"The trial of this machine was converted to a paid license",
+			"TRIAL_CONVERTED",
+			nil,
+		))
+		return
+	}
+
+	if !checkLicenseUsable(ctx, db, application, &license) {
+		return
+	}
+
+	// The key is derived again on every request, so it follows key format changes made since the trial was issued
+	if keyHash := utils.HashLicenseKey(key); keyHash != license.KeyHash {
+		if err := db.Model(&license).Updates(models.License{KeyHash: keyHash, KeyHint: utils.LicenseKeyHint(key)}).Error; err != nil {
+			ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+				fasthttp.StatusInternalServerError,
+				"Failed to update trial key",
+				"UPDATE_FAILED",
+				nil,
+			))
+			return
+		}
+	}
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{
+		"message":    "Trial already issued",
+		"key":        key,
+		"expires_on": formatExpiry(utils.NewTimeFormatter(ctx), &license),
+		"status":     license.Status,
+	})
+}
+
+// ConvertTrial turns a trial license into a paid one with a new key, keeping its activations.
+// @Summary Convert a trial license
+// @Tags Licenses
+// @Description Replace the key of a trial license with a paid key; the machines it is activated on stay activated and the new expiry counts from now
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param license_id path string true "License ID"
+// @Param request body models.ConvertTrialRequest true "Paid license data"
+// @Param time_format query string false "Set to legacy for timestamps in the old format"
+// @Success 200 {object} map[string]interface{} "OK"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/licenses/{license_id}/convert [patch]
+func ConvertTrial(ctx *gin.Context, db *gorm.DB) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	request := ctx.MustGet("request").(*models.ConvertTrialRequest)
+	licenseID := ctx.Param("license_id")
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+	username := userInfo["preferred_username"].(string)
+
+	var application models.Application
+	if err := db.Where("application_id = ? AND user_id = ?", applicationID, userID).First(&application).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"Application not found or does not belong to the user",
+			"APPLICATION_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	var license models.License
+	if err := db.Scopes(whereLicense(licenseID)).Where("application_id = ? AND user_id = ?", applicationID, userID).First(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"License not found or does not belong to the user",
+			"LICENSE_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	if !license.Trial {
+		ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+			fasthttp.StatusConflict,
+			"License is not a trial",
+			"LICENSE_NOT_TRIAL",
+			nil,
+		))
+		return
+	}
+
+	// Banned, frozen and revoked trials keep their status until they are dealt with separately
+	if license.Status != models.LicenseActive && license.Status != models.LicenseExpired {
+		ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+			fasthttp.StatusConflict,
+			"Only active or expired trials can be converted",
+			"LICENSE_NOT_CONVERTIBLE",
+			map[string]models.LicenseStatus{"status": license.Status},
+		))
+		return
+	}
+
+	licenseRequest := request.LicenseRequest()
+	licenseRequest.MaxActivations = license.MaxActivations
+	licenseRequest.MaxSessions = license.MaxSessions
+	generation, err := newLicenseGeneration(db, &application, licenseRequest, username)
+	if err != nil {
+		writeLicenseGenerationError(ctx, err)
+		return
+	}
+
+	keys, err := generateUniqueKeys(db, applicationID, licenseRequest.Prefix, generation.keyMask, generation.checksumSecret, 1)
+	if err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to generate license key",
+			"KEY_GENERATION_FAILED",
+			nil,
+		))
+		return
+	}
+	key := keys[0]
+
+	from := license.Status
+	if license.Status == models.LicenseExpired && !transitionLicense(ctx, &license, models.LicenseActive) {
+		return
+	}
+
+	now := time.Now().UTC()
+	license.ExpiresOn = nil
+	if !licenseRequest.Lifetime {
+		expiresOn, err := utils.AddDurationText(now, generation.duration)
+		if err != nil {
+			ctx.JSON(fasthttp.StatusBadRequest, utils.NewErrorResponse(
+				fasthttp.StatusBadRequest,
+				"Invalid license duration",
+				"INVALID_DURATION",
+				nil,
+			))
+			return
+		}
+		license.ExpiresOn = &expiresOn
+	}
+	license.KeyHash = utils.HashLicenseKey(key)
+	license.KeyHint = utils.LicenseKeyHint(key)
+	license.KeyVersion = generation.keyVersion
+	license.Duration = generation.duration
+	license.Lifetime = licenseRequest.Lifetime
+	license.Trial = false
+	license.GeneratedBy = username
+	license.Entitlements = licenseRequest.Entitlements.Compact()
+
+	// Only the converted columns are written so usage consumed in the meantime is kept
+	if err := saveLicenseTransition(db, &license, from, "ExpiresOn", "KeyHash", "KeyHint", "KeyVersion", "Duration", "Lifetime", "Trial", "GeneratedBy", "Entitlements"); err != nil {
+		if errors.Is(err, errLicenseStatusChanged) {
+			writeLicenseStatusChanged(ctx)
+			return
+		}
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to convert trial",
+			"CONVERT_FAILED",
+			nil,
+		))
+		return
+	}
+
+	// Invalidate the cache for the application's licenses
+	licensesCacheKey := "application:" + applicationID + ":licenses"
+	redisClient.Del(ctx, licensesCacheKey)
+	log.Printf("Cache invalidated for application %s licenses after trial conversion", applicationID)
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{
+		"message":    "Trial converted successfully",
+		"id":         license.ID,
+		"key":        key,
+		"key_hint":   license.KeyHint,
+		"expires_on": formatExpiry(utils.NewTimeFormatter(ctx), &license),
+		"status":     license.Status,
+	})
+}
+
+// trialKey derives the trial key of a HWID. It has the application's key format and checksum,
+// so it passes the same checks as generated keys.
+func trialKey(db *gorm.DB, application *models.Application, hwid string) (string, error) {
+	mask := application.Settings.KeyMask
+	if mask == "" {
+		mask = trialKeyMask
+	}
+	keyMask, err := utils.ParseKeyMask(mask, application.Settings.KeyAlphabet)
+	if err != nil {
+		return "", err
+	}
+
+	key := keyMask.Derive("TRIAL", "trial:"+application.ApplicationID+":"+hwid)
+	if application.Settings.KeyChecksum {
+		if err := ensureChecksumSecret(db, application); err != nil {
+			return "", err
+		}
+		key = utils.AppendKeyChecksum(application.ChecksumSecret, key)
+	}
+	return key, nil
+}
+
+// issueTrial creates a trial license activated on the HWID and claims the HWID's trial.
+// It fails when the HWID has already claimed a trial.
+func issueTrial(db *gorm.DB, application *models.Application, key string, hwid string, ip string) (*models.License, error) {
+	trialDays := application.Settings.TrialDurationDays
+	if trialDays == 0 {
+		trialDays = defaultTrialDurationDays
+	}
+
+	now := time.Now().UTC()
+	expiresOn := now.AddDate(0, 0, trialDays)
+	license := models.License{
+		UserID:         application.UserID,
+		ApplicationID:  application.ApplicationID,
+		KeyHash:        utils.HashLicenseKey(key),
+		KeyHint:        utils.LicenseKeyHint(key),
+		CreatedOn:      &now,
+		Duration:       utils.FormatDuration(trialDays, "Days"),
+		GeneratedBy:    "Trial",
+		Status:         models.LicenseNotUsed,
+		IP:             ip,
+		FirstIP:        ip,
+		HWID:           hwid,
+		MaxActivations: 1,
+		Trial:          true,
+	}
+	if application.Settings.KeyChecksum {
+		license.KeyVersion = utils.KeyChecksumVersion
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&license).Error; err != nil {
+			return err
+		}
+
+		// The unique index on application and HWID rejects a second claim
+		claim := models.TrialClaim{
+			ApplicationID: application.ApplicationID,
+			HWID:          hwid,
+			LicenseID:     license.ID,
+			IP:            ip,
+			ClaimedOn:     now,
+		}
+		if err := tx.Create(&claim).Error; err != nil {
+			return err
+		}
+
+		if err := activateMachine(tx, &license, hwid, ip, now, true); err != nil {
+			return err
+		}
+		from := license.Status
+		if err := license.Transition(models.LicenseActive); err != nil {
+			return err
+		}
+		license.UsedOn = &now
+		license.ExpiresOn = &expiresOn
+		license.LastSeenOn = &now
+		return saveLicenseTransition(tx, &license, from, "UsedOn", "ExpiresOn", "LastSeenOn")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &license, nil
+}
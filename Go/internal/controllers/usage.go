@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"log"
+
+	"backend/internal/models"
+	"backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/valyala/fasthttp"
+	"gorm.io/gorm"
+)
+
+// ConsumeUsage takes units from the quota of a metered license.
+// @Summary Consume license usage
+// @Tags Licenses
+// @Description Consume units of a metered license activated on the HWID; nothing is consumed when fewer units are left than requested
+// @Accept json
+// @Produce json
+// @Param X-Signature header string false "HMAC-SHA256 request signature, required when the application requires signed requests"
+// @Param X-Signature-Timestamp header string false "Unix timestamp the request was signed at"
+// @Param application_id path string true "Application ID"
+// @Param request body models.ConsumeUsageRequest true "License key, HWID and units to consume"
+// @Success 200 {object} map[string]interface{} "OK"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 409 {object} map[string]string "Conflict"
+// @Failure 410 {object} map[string]string "Gone"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/public/applications/{application_id}/usage [post]
+func ConsumeUsage(ctx *gin.Context, db *gorm.DB) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	request := ctx.MustGet("request").(*models.ConsumeUsageRequest)
+	applicationID := ctx.Param("application_id")
+
+	application, ok := findPublicApplication(ctx, db, applicationID)
+	if !ok {
+		return
+	}
+
+	if !checkBlacklist(ctx, db, applicationID, request.HWID, utils.GetClientIP(ctx)) {
+		return
+	}
+
+	var license models.License
+	if err := db.Where("key_hash = ? AND application_id = ?", utils.HashLicenseKey(request.Key), applicationID).First(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"License not found",
+			"LICENSE_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	if !checkLicenseUsable(ctx, db, application, &license) || checkActiveLicense(ctx, db, &license, request.HWID) == nil {
+		return
+	}
+
+	if license.UsageQuota == 0 {
+		ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+			fasthttp.StatusConflict,
+			"License is not metered",
+			"LICENSE_NOT_METERED",
+			nil,
+		))
+		return
+	}
+
+	// The balance is checked by the update itself, so concurrent calls from any number of instances cannot overdraw it
+	result := db.Model(&models.License{}).
+		Where("id = ? AND usage_quota > 0 AND usage_count + ? <= usage_quota", license.ID, request.Units).
+		Update("usage_count", gorm.Expr("usage_count + ?", request.Units))
+	if result.Error != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to consume usage",
+			"USAGE_UPDATE_FAILED",
+			nil,
+		))
+		return
+	}
+	consumed := result.RowsAffected > 0
+
+	if err := db.Select("usage_quota", "usage_count").Where("id = ?", license.ID).First(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to read usage",
+			"USAGE_UPDATE_FAILED",
+			nil,
+		))
+		return
+	}
+	remaining := license.UsageQuota - license.UsageCount
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	if !consumed {
+		ctx.JSON(fasthttp.StatusConflict, utils.NewErrorResponse(
+			fasthttp.StatusConflict,
+			"Usage quota exhausted",
+			"QUOTA_EXHAUSTED",
+			map[string]int64{"requested": request.Units, "remaining": remaining, "usage_quota": license.UsageQuota},
+		))
+		return
+	}
+
+	// Invalidate the cache for the application's licenses
+	licensesCacheKey := "application:" + applicationID + ":licenses"
+	redisClient.Del(ctx, licensesCacheKey)
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{
+		"consumed":    request.Units,
+		"usage_count": license.UsageCount,
+		"usage_quota": license.UsageQuota,
+		"remaining":   remaining,
+	})
+}
+
+// UpdateLicenseUsage changes the quota of a metered license or resets its consumed units.
+// @Summary Update license usage
+// @Tags Licenses
+// @Description Change the usage quota of a license or set its consumed units back to 0; a quota of 0 stops metering the license
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token" default(Bearer <token>)
+// @Param application_id path string true "Application ID"
+// @Param license_id path string true "License ID"
+// @Param request body models.UpdateLicenseUsageRequest true "Usage quota and reset"
+// @Success 200 {object} map[string]interface{} "OK"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /api/v1/private/applications/{application_id}/licenses/{license_id}/usage [patch]
+func UpdateLicenseUsage(ctx *gin.Context, db *gorm.DB) {
+	redisClient, ok := ctx.MustGet("redisClient").(*redis.Client)
+	if !ok {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to access Redis client",
+			"REDIS_CLIENT_ERROR",
+			nil,
+		))
+		return
+	}
+
+	request := ctx.MustGet("request").(*models.UpdateLicenseUsageRequest)
+	licenseID := ctx.Param("license_id")
+	applicationID := ctx.Param("application_id")
+
+	userInfo := ctx.MustGet("userInfo").(map[string]interface{})
+	userID := userInfo["sub"].(string)
+
+	var license models.License
+	if err := db.Scopes(whereLicense(licenseID)).Where("application_id = ? AND user_id = ?", applicationID, userID).First(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusNotFound, utils.NewErrorResponse(
+			fasthttp.StatusNotFound,
+			"License not found or does not belong to the user",
+			"LICENSE_NOT_FOUND",
+			nil,
+		))
+		return
+	}
+
+	// Only the changed columns are written, so units consumed in the meantime are not overwritten
+	updates := map[string]interface{}{}
+	if request.UsageQuota != nil {
+		updates["usage_quota"] = *request.UsageQuota
+	}
+	if request.ResetUsage {
+		updates["usage_count"] = 0
+	}
+	if len(updates) > 0 {
+		if err := db.Model(&license).Updates(updates).Error; err != nil {
+			ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+				fasthttp.StatusInternalServerError,
+				"Failed to update usage",
+				"UPDATE_FAILED",
+				nil,
+			))
+			return
+		}
+	}
+
+	if err := db.Select("usage_quota", "usage_count").Where("id = ?", license.ID).First(&license).Error; err != nil {
+		ctx.JSON(fasthttp.StatusInternalServerError, utils.NewErrorResponse(
+			fasthttp.StatusInternalServerError,
+			"Failed to read usage",
+			"UPDATE_FAILED",
+			nil,
+		))
+		return
+	}
+
+	// Invalidate the cache for the application's licenses
+	licensesCacheKey := "application:" + applicationID + ":licenses"
+	redisClient.Del(ctx, licensesCacheKey)
+	log.Printf("Cache invalidated for application %s licenses after usage update", applicationID)
+
+	ctx.JSON(fasthttp.StatusOK, gin.H{
+		"message":     "Usage updated successfully",
+		"usage_quota": license.UsageQuota,
+		"usage_count": license.UsageCount,
+	})
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestConsumeUsageConcurrentCallsDoNotOverdraw(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := openTestDB(t)
+	application := createTestApplication(t, db)
+
+	const (
+		key      = "USAGE-TEST-KEY"
+		hwid     = "hwid-1"
+		quota    = 10
+		units    = 3
+		requests = 12
+	)
+
+	now := time.Now().UTC()
+	expiresOn := now.Add(24 * time.Hour)
+	license := models.License{
+		UserID:        application.UserID,
+		ApplicationID: application.ApplicationID,
+		KeyHash:       utils.HashLicenseKey(key),
+		KeyHint:       utils.LicenseKeyHint(key),
+		Status:        models.LicenseActive,
+		HWID:          hwid,
+		UsedOn:        &now,
+		ExpiresOn:     &expiresOn,
+		UsageQuota:    quota,
+	}
+	if err := db.Create(&license).Error; err != nil {
+		t.Fatalf("create license: %v", err)
+	}
+	activation := models.Activation{LicenseID: license.ID, ApplicationID: application.ApplicationID, HWID: hwid, FirstSeenOn: now, LastSeenOn: now}
+	if err := db.Create(&activation).Error; err != nil {
+		t.Fatalf("create activation: %v", err)
+	}
+
+	redisClient := unreachableRedis()
+	statuses := make([]int, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			recorder := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(recorder)
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/api/v1/public/applications/"+application.ApplicationID+"/usage", nil)
+			ctx.Params = gin.Params{{Key: "application_id", Value: application.ApplicationID}}
+			ctx.Set("redisClient", redisClient)
+			ctx.Set("request", &models.ConsumeUsageRequest{Key: key, HWID: hwid, Units: units})
+
+			ConsumeUsage(ctx, db)
+			statuses[i] = recorder.Code
+		}(i)
+	}
+	wg.Wait()
+
+	consumed, exhausted := 0, 0
+	for _, status := range statuses {
+		switch status {
+		case http.StatusOK:
+			consumed++
+		case http.StatusConflict:
+			exhausted++
+		default:
+			t.Errorf("unexpected status %d", status)
+		}
+	}
+	if consumed != quota/units {
+		t.Errorf("%d calls consumed usage, want %d", consumed, quota/units)
+	}
+	if exhausted != requests-quota/units {
+		t.Errorf("%d calls were rejected, want %d", exhausted, requests-quota/units)
+	}
+
+	if err := db.Select("usage_count").First(&license, license.ID).Error; err != nil {
+		t.Fatalf("load license: %v", err)
+	}
+	if license.UsageCount != quota/units*units {
+		t.Errorf("usage_count = %d, want %d", license.UsageCount, quota/units*units)
+	}
+}
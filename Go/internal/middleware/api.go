@@ -4,6 +4,7 @@ import (
 	"backend/internal/models"
 	"backend/internal/utils"
 	"net/http"
+	"reflect"
 
 	"github.com/gin-gonic/gin"
 )
@@ -46,9 +47,12 @@ func ParamValidation(paramNames ...string) gin.HandlerFunc {
 		for _, paramName := range paramNames {
 			id := c.Param(paramName)
 			var err error
-			if paramName == "license_id" {
+			switch paramName {
+			case "license_id":
 				err = models.ValidateLicenseID(id)
-			} else {
+			case "activation_id", "entry_id":
+				err = models.ValidateNumericID(id)
+			default:
 				err = models.ValidateUUID(id)
 			}
 			if err != nil {
@@ -67,8 +71,10 @@ func ParamValidation(paramNames ...string) gin.HandlerFunc {
 }
 
 // JSONValidation validates the JSON request body and validates fields
-func JSONValidation(request interface{}) gin.HandlerFunc {
+func JSONValidation(requestType interface{}) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
+		// Bind into a fresh value per request so fields never leak between requests
+		request := reflect.New(reflect.TypeOf(requestType).Elem()).Interface()
 		if err := ctx.ShouldBindJSON(request); err != nil {
 			ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse(
 				http.StatusBadRequest,
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+// clientSignatureWindow is how far the timestamp of a signed request may be from the server's clock
+const clientSignatureWindow = 5 * time.Minute
+
+// ClientSignature verifies the HMAC signature of public client requests, see utils.SignClientRequest.
+// Unsigned requests are let through unless the application requires signed requests, signed ones must always verify.
+// A signature is only accepted once, so a captured request cannot be replayed within the timestamp window.
+func ClientSignature(db *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		applicationID := ctx.Param("application_id")
+
+		var application models.Application
+		if err := db.Where("application_id = ?", applicationID).First(&application).Error; err != nil {
+			// Unknown applications are reported by the handler
+			ctx.Next()
+			return
+		}
+
+		signature := ctx.GetHeader(utils.ClientSignatureHeader)
+		if signature == "" {
+			if application.Settings.RequireSignedRequests {
+				abortClientSignature(ctx, "Request signature is required", "SIGNATURE_REQUIRED")
+				return
+			}
+			ctx.Next()
+			return
+		}
+
+		now := time.Now()
+		timestamp, err := strconv.ParseInt(ctx.GetHeader(utils.ClientTimestampHeader), 10, 64)
+		if err != nil {
+			abortClientSignature(ctx, "Request timestamp is missing or invalid", "INVALID_SIGNATURE_TIMESTAMP")
+			return
+		}
+		if skew := now.Sub(time.Unix(timestamp, 0)); skew > clientSignatureWindow || skew < -clientSignatureWindow {
+			abortClientSignature(ctx, "Request timestamp is outside the allowed window", "SIGNATURE_EXPIRED")
+			return
+		}
+
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, utils.NewErrorResponse(
+				http.StatusBadRequest,
+				"Invalid request format",
+				"INVALID_JSON",
+				nil,
+			))
+			ctx.Abort()
+			return
+		}
+		// Put the body back for the JSON validation that follows
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		if !utils.VerifyClientRequest(application.ClientSecrets(now), signature, ctx.Request.Method, ctx.Request.URL.Path, timestamp, body) {
+			abortClientSignature(ctx, "Request signature is invalid", "INVALID_SIGNATURE")
+			return
+		}
+
+		if redisClient, ok := ctx.MustGet("redisClient").(*redis.Client); ok {
+			fresh, err := redisClient.SetNX(ctx, "signature:"+applicationID+":"+signature, 1, 2*clientSignatureWindow).Result()
+			if err == nil && !fresh {
+				abortClientSignature(ctx, "Request was already received", "REPLAYED_REQUEST")
+				return
+			}
+		}
+
+		ctx.Next()
+	}
+}
+
+func abortClientSignature(ctx *gin.Context, message string, code string) {
+	ctx.JSON(http.StatusUnauthorized, utils.NewErrorResponse(
+		http.StatusUnauthorized,
+		message,
+		code,
+		nil,
+	))
+	ctx.Abort()
+}
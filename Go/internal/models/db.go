@@ -1,30 +1,198 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
 // Application model
 type Application struct {
 	gorm.Model
-	ApplicationID string `gorm:"primaryKey;size:36"`
-	AppName       string `gorm:"not null;uniqueIndex:idx_appname_userid"`
-	UserID        string `gorm:"not null;size:36;uniqueIndex:idx_appname_userid"`
+	ApplicationID                 string              `gorm:"primaryKey;size:36"`
+	AppName                       string              `gorm:"not null;uniqueIndex:idx_appname_userid"`
+	UserID                        string              `gorm:"not null;size:36;uniqueIndex:idx_appname_userid"`
+	PublicKey                     string              `gorm:"size:64"`           // Base64 Ed25519 public key used to verify license tokens
+	PrivateKey                    string              `gorm:"size:128" json:"-"` // Base64 Ed25519 private key, never exposed
+	ChecksumSecret                string              `gorm:"size:64" json:"-"`  // HMAC secret for key checksums, shared with the client SDK
+	Settings                      ApplicationSettings `gorm:"embedded;embeddedPrefix:setting_"`
+	Paused                        bool                // Paused applications reject every public request for their licenses
+	PausedOn                      *time.Time
+	ClientSecret                  string     `gorm:"size:64" json:"-"` // HMAC secret clients sign public requests with
+	PreviousClientSecret          string     `gorm:"size:64" json:"-"` // Secret replaced by the last rotation, accepted until it expires
+	PreviousClientSecretExpiresOn *time.Time `json:"-"`
+}
+
+// ClientSecrets returns the secrets client requests may be signed with at the given time
+func (application *Application) ClientSecrets(now time.Time) []string {
+	secrets := []string{application.ClientSecret}
+	if application.PreviousClientSecret != "" && application.PreviousClientSecretExpiresOn != nil && now.Before(*application.PreviousClientSecretExpiresOn) {
+		secrets = append(secrets, application.PreviousClientSecret)
+	}
+	return secrets
+}
+
+// ApplicationSettings holds the per-application policy, stored as columns on the application row
+type ApplicationSettings struct {
+	HWIDResetEnabled       bool                    `json:"hwid_reset_enabled"`                             // Allow customers to reset their own HWID
+	HWIDResetLimit         int                     `json:"hwid_reset_limit"`                               // Customer resets allowed per period, 0 for unlimited
+	HWIDResetPeriodDays    int                     `json:"hwid_reset_period_days"`                         // Window the reset limit applies to
+	HWIDResetCooldownHours int                     `json:"hwid_reset_cooldown_hours"`                      // Minimum time between customer resets
+	KeyAlphabet            string                  `gorm:"size:20" json:"key_alphabet"`                    // Alphabet of the X and A mask tokens, empty for the default
+	KeyMask                string                  `gorm:"size:100" json:"key_mask"`                       // Declared key format, generated keys use it and redeemed keys must match it
+	KeyChecksum            bool                    `json:"key_checksum"`                                   // Append a checksum segment to generated keys
+	RequireKeyChecksum     bool                    `json:"require_key_checksum"`                           // Reject keys without a valid checksum before looking them up
+	Entitlements           []EntitlementDefinition `gorm:"serializer:json" json:"entitlements"`            // Features and limits licenses can be given
+	SessionTTLSeconds      int                     `json:"session_ttl_seconds"`                            // Lifetime of a session lease without a heartbeat, 0 for the default
+	TrialEnabled           bool                    `json:"trial_enabled"`                                  // Issue a trial license to every HWID that asks for one
+	TrialDurationDays      int                     `json:"trial_duration_days"`                            // Length of a trial, 0 for the default
+	HWIDLockDisabled       bool                    `json:"hwid_lock_disabled"`                             // Let licenses be redeemed on any number of machines, activations are still recorded
+	IPLock                 bool                    `json:"ip_lock"`                                        // Only accept redemptions from the IP a license was first redeemed from
+	ExpiryStart            ExpiryStart             `gorm:"size:20" json:"expiry_start"`                    // When the duration of a license starts counting, empty for at activation
+	GracePeriodHours       int                     `json:"grace_period_hours"`                             // Time a license keeps working after it expires
+	AllowedClientVersions  []string                `gorm:"serializer:json" json:"allowed_client_versions"` // Client versions that may redeem licenses, empty for any
+	AllowedNetworks        []string                `gorm:"serializer:json" json:"allowed_networks"`        // IPs and CIDR ranges licenses may be used from, empty for any
+	IPLockIPv4PrefixLength int                     `json:"ip_lock_ipv4_prefix_length"`                     // Subnet an IPv4 lock covers, 0 for the single address
+	IPLockIPv6PrefixLength int                     `json:"ip_lock_ipv6_prefix_length"`                     // Subnet an IPv6 lock covers, 0 for the single address
+	AutoBlacklistOnBan     bool                    `json:"auto_blacklist_on_ban"`                          // Blacklist the HWIDs and IP of a license when it is banned
+	RequireSignedRequests  bool                    `json:"require_signed_requests"`                        // Reject public requests without a client signature
 }
 
 // License model
 type License struct {
 	gorm.Model
-	UserID        string `gorm:"index;size:36"`                                     // UUID is 36 characters
-	ApplicationID string `gorm:"size:36;not null;uniqueIndex:idx_application_key"`  // UUID is 36 characters
-	Key           string `gorm:"size:100;not null;uniqueIndex:idx_application_key"` // Composite unique index with ApplicationID
-	Note          string `gorm:"size:255"`                                          // Limiting note to 255 characters
-	CreatedOn     string `gorm:"size:50"`
-	Duration      string `gorm:"size:50"`
-	GeneratedBy   string `gorm:"size:50"`
-	UsedOn        string `gorm:"size:50"`
-	ExpiresOn     string `gorm:"size:50"`
-	Status        string `gorm:"size:50"`
-	IP            string `gorm:"size:45"`  // IPv6 can be up to 45 characters
-	HWID          string `gorm:"size:255"` // Can vary
+	UserID             string       `gorm:"index;size:36"`                                                  // UUID is 36 characters
+	ApplicationID      string       `gorm:"size:36;not null;uniqueIndex:idx_application_key_hash"`          // UUID is 36 characters
+	KeyHash            string       `gorm:"size:64;not null;uniqueIndex:idx_application_key_hash" json:"-"` // Keyed hash of the key, the key itself is only shown when generated
+	KeyHint            string       `gorm:"size:40"`                                                        // Prefix and last characters of the key for display
+	KeyVersion         int          // Checksum scheme the key was generated with, 0 for keys without a checksum
+	Entitlements       Entitlements `gorm:"serializer:json"` // Features and limits granted to the license
+	AllowedNetworks    []string     `gorm:"serializer:json"` // IPs and CIDR ranges the license may be used from, on top of the application's
+	Note               string       `gorm:"size:255"`        // Limiting note to 255 characters
+	CreatedOn          *time.Time
+	Duration           string     `gorm:"size:50"`
+	GeneratedBy        string     `gorm:"size:50"`
+	UsedOn             *time.Time // Nil until the license is first redeemed
+	ExpiresOn          *time.Time `gorm:"index"` // Nil until redeemed, and for lifetime licenses
+	LastSeenOn         *time.Time
+	Status             LicenseStatus `gorm:"size:50"`
+	IP                 string        `gorm:"size:45"`   // IPv6 can be up to 45 characters
+	FirstIP            string        `gorm:"size:45"`   // IP of the first redemption, the IP lock is checked against it
+	HWID               string        `gorm:"size:255"`  // First activated HWID, kept for display
+	MaxActivations     int           `gorm:"default:1"` // Number of machines the license may be activated on
+	MaxSessions        int           // Number of sessions that may be checked out at the same time, 0 for unlimited
+	UsageQuota         int64         // Units a metered license may consume, 0 for licenses that are not metered
+	UsageCount         int64         // Units consumed so far, only changed by conditional updates so it never exceeds UsageQuota
+	Lifetime           bool          // Lifetime licenses never expire and have no ExpiresOn
+	Trial              bool          // Issued by the trial endpoint, its key is derived from the application and HWID
+	StatusBeforeBan    LicenseStatus `gorm:"size:50"` // Restored when the license is unbanned
+	BannedUntil        *time.Time    // Nil for permanent bans
+	StatusBeforeFreeze LicenseStatus `gorm:"size:50"` // Restored when the license is resumed
+	FrozenOn           *time.Time    // Set while the license is frozen
+	FrozenSeconds      int64         // Time spent frozen before the first redemption, added to expiry counted from creation
+	Bans               []LicenseBan  `gorm:"foreignKey:LicenseID"`
+}
+
+// Activation model, one row per machine a license is activated on
+type Activation struct {
+	gorm.Model
+	LicenseID     uint   `gorm:"not null;uniqueIndex:idx_license_hwid"`
+	ApplicationID string `gorm:"size:36;not null;index"`
+	HWID          string `gorm:"size:255;not null;uniqueIndex:idx_license_hwid"`
+	IP            string `gorm:"size:45"`
+	FirstSeenOn   time.Time
+	LastSeenOn    time.Time
+}
+
+// HWIDReset model, an audit record of a HWID being removed from a license
+type HWIDReset struct {
+	gorm.Model
+	LicenseID     uint   `gorm:"index;not null"`
+	ApplicationID string `gorm:"size:36;not null;index"`
+	HWID          string `gorm:"size:255"`
+	IP            string `gorm:"size:45"`
+	SelfService   bool   // True when the customer reset their own HWID
+	ResetBy       string `gorm:"size:50"`
+	ResetOn       time.Time
+}
+
+// LicenseBan model, one row per time a license was banned
+type LicenseBan struct {
+	gorm.Model
+	LicenseID     uint   `gorm:"index;not null"`
+	ApplicationID string `gorm:"size:36;not null;index"`
+	Reason        string `gorm:"size:255"`
+	BannedBy      string `gorm:"size:50"`
+	BannedOn      time.Time
+	BannedUntil   *time.Time // Nil for permanent bans
+	UnbannedBy    string     `gorm:"size:50"`
+	UnbannedOn    *time.Time // Nil while the ban is in effect
+}
+
+// BlacklistKind tells what a blacklist entry matches
+type BlacklistKind string
+
+const (
+	BlacklistHWID BlacklistKind = "hwid" // Matches the HWID exactly
+	BlacklistIP   BlacklistKind = "ip"   // Matches an IP address or any IP in a CIDR range
+)
+
+// BlacklistEntry model, a HWID or IP range that may not redeem any license of the application
+type BlacklistEntry struct {
+	gorm.Model
+	ApplicationID string        `gorm:"size:36;not null;uniqueIndex:idx_blacklist_entry"`
+	Kind          BlacklistKind `gorm:"size:10;not null;uniqueIndex:idx_blacklist_entry"`
+	Value         string        `gorm:"size:255;not null;uniqueIndex:idx_blacklist_entry"` // IPs and ranges are stored in canonical CIDR form
+	Reason        string        `gorm:"size:255"`
+	AddedBy       string        `gorm:"size:50"`
+	AddedOn       time.Time
+	LicenseID     *uint // License whose ban added the entry, nil for entries added by hand
+}
+
+// TrialClaim model, one row per HWID that was issued a trial. It is kept when the trial license is deleted,
+// so a HWID never gets a second trial.
+type TrialClaim struct {
+	gorm.Model
+	ApplicationID string `gorm:"size:36;not null;uniqueIndex:idx_trial_application_hwid"`
+	HWID          string `gorm:"size:255;not null;uniqueIndex:idx_trial_application_hwid"`
+	LicenseID     uint   `gorm:"not null;index"`
+	IP            string `gorm:"size:45"`
+	ClaimedOn     time.Time
+}
+
+// LicenseJobStatus is the state of a background license generation job
+type LicenseJobStatus string
+
+const (
+	LicenseJobQueued    LicenseJobStatus = "queued"
+	LicenseJobRunning   LicenseJobStatus = "running"
+	LicenseJobCompleted LicenseJobStatus = "completed"
+	LicenseJobFailed    LicenseJobStatus = "failed"
+)
+
+// LicenseJob model, a large batch of licenses generated in chunks in the background
+type LicenseJob struct {
+	gorm.Model
+	JobID         string           `gorm:"size:36;not null;uniqueIndex"`
+	ApplicationID string           `gorm:"size:36;not null;index"`
+	UserID        string           `gorm:"size:36;index"`
+	Username      string           `gorm:"size:50"` // Recorded as GeneratedBy on the licenses
+	Request       LicenseRequest   `gorm:"serializer:json"`
+	Status        LicenseJobStatus `gorm:"size:20;index"`
+	Total         int
+	Generated     int               // Licenses created so far, chunks are committed together with this count
+	Error         string            `gorm:"size:255"`
+	LockedBy      string            `gorm:"size:100"` // Worker running the job
+	LockedUntil   *time.Time        // Another worker may take the job over once this passes
+	CompletedOn   *time.Time        // Set when the job completes or fails
+	KeysExpireOn  *time.Time        // The generated keys are deleted after this
+	Chunks        []LicenseJobChunk `gorm:"foreignKey:LicenseJobID"`
+}
+
+// LicenseJobChunk model, the keys created by one chunk of a job, encrypted until they expire
+type LicenseJobChunk struct {
+	gorm.Model
+	LicenseJobID uint   `gorm:"not null;uniqueIndex:idx_license_job_chunk"`
+	Chunk        int    `gorm:"not null;uniqueIndex:idx_license_job_chunk"`
+	Keys         string `gorm:"type:text"` // Keys sealed with utils.SealLicenseKeys
 }
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// EntitlementKind tells how the value of an entitlement is read
+type EntitlementKind string
+
+const (
+	EntitlementFeature EntitlementKind = "feature" // A feature flag, granted with the value 1
+	EntitlementLimit   EntitlementKind = "limit"   // A numeric limit such as a seat count
+)
+
+// maxEntitlements bounds the entitlements an application declares and a license holds
+const maxEntitlements = 50
+
+var entitlementNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,49}$`)
+
+// EntitlementDefinition declares an entitlement the licenses of an application can be given
+type EntitlementDefinition struct {
+	Name string          `json:"name"`
+	Kind EntitlementKind `json:"kind"`
+}
+
+// Entitlements maps entitlement names to their values on a license, entitlements that are not granted are left out
+type Entitlements map[string]int64
+
+// Compact returns the entitlements without the ones set to 0, or nil when none are left
+func (entitlements Entitlements) Compact() Entitlements {
+	var compacted Entitlements
+	for name, value := range entitlements {
+		if value == 0 {
+			continue
+		}
+		if compacted == nil {
+			compacted = Entitlements{}
+		}
+		compacted[name] = value
+	}
+	return compacted
+}
+
+// CheckEntitlements returns an error naming the first entitlement the application does not declare
+// or whose value does not fit its kind
+func (settings *ApplicationSettings) CheckEntitlements(entitlements Entitlements) error {
+	kinds := make(map[string]EntitlementKind, len(settings.Entitlements))
+	for _, definition := range settings.Entitlements {
+		kinds[definition.Name] = definition.Kind
+	}
+
+	names := make([]string, 0, len(entitlements))
+	for name := range entitlements {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		kind, ok := kinds[name]
+		if !ok {
+			return fmt.Errorf("entitlement %q is not declared by the application", name)
+		}
+		if kind == EntitlementFeature && entitlements[name] != 0 && entitlements[name] != 1 {
+			return fmt.Errorf("feature %q can only be 0 or 1", name)
+		}
+	}
+	return nil
+}
+
+// validateEntitlements checks the names and values of entitlements in a request
+func validateEntitlements(value interface{}) error {
+	entitlements, _ := value.(Entitlements)
+	if len(entitlements) > maxEntitlements {
+		return fmt.Errorf("at most %d entitlements are allowed", maxEntitlements)
+	}
+	for name, value := range entitlements {
+		if !entitlementNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid entitlement name %q", name)
+		}
+		if value < 0 {
+			return fmt.Errorf("entitlement %q cannot be negative", name)
+		}
+	}
+	return nil
+}
+
+// validateEntitlementDefinitions checks the entitlements an application declares
+func validateEntitlementDefinitions(value interface{}) error {
+	definitions, _ := validation.Indirect(value)
+	list, _ := definitions.([]EntitlementDefinition)
+	if len(list) > maxEntitlements {
+		return fmt.Errorf("at most %d entitlements are allowed", maxEntitlements)
+	}
+
+	seen := make(map[string]bool, len(list))
+	for _, definition := range list {
+		if !entitlementNamePattern.MatchString(definition.Name) {
+			return fmt.Errorf("invalid entitlement name %q", definition.Name)
+		}
+		if seen[definition.Name] {
+			return fmt.Errorf("entitlement %q is declared twice", definition.Name)
+		}
+		seen[definition.Name] = true
+		if definition.Kind != EntitlementFeature && definition.Kind != EntitlementLimit {
+			return errors.New("entitlement kind must be feature or limit")
+		}
+	}
+	return nil
+}
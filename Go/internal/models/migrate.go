@@ -0,0 +1,200 @@
+package models
+
+import (
+	"time"
+
+	"backend/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// legacyTimestampColumns lists the columns that held formatted strings such as "2006-01-02 @ 03:04 PM"
+// in server-local time before timestamps were stored as time values
+var legacyTimestampColumns = []struct {
+	model   interface{}
+	columns []string
+}{
+	{&License{}, []string{"created_on", "used_on", "expires_on", "last_seen_on", "banned_until", "frozen_on"}},
+	{&Activation{}, []string{"first_seen_on", "last_seen_on"}},
+	{&HWIDReset{}, []string{"reset_on"}},
+	{&LicenseBan{}, []string{"banned_on", "banned_until", "unbanned_on"}},
+}
+
+// legacyTimestampPlaceholders were stored instead of a timestamp when none was set
+var legacyTimestampPlaceholders = []string{"", "N/A", "Never"}
+
+// MigrationResult reports the data Migrate rewrote
+type MigrationResult struct {
+	HashedKeys int // Licenses whose plaintext key was replaced with its hash
+}
+
+// Migrate creates or updates the schema and backfills data for older rows
+func Migrate(db *gorm.DB) (MigrationResult, error) {
+	var result MigrationResult
+
+	hashedKeys, err := hashLegacyKeys(db)
+	if err != nil {
+		return result, err
+	}
+	result.HashedKeys = hashedKeys
+
+	if err := db.AutoMigrate(&Application{}, &License{}, &Activation{}, &HWIDReset{}, &LicenseBan{}, &LicenseJob{}, &LicenseJobChunk{}, &TrialClaim{}, &BlacklistEntry{}); err != nil {
+		return result, err
+	}
+
+	if err := migrateLegacyTimestamps(db); err != nil {
+		return result, err
+	}
+
+	return result, backfillActivations(db)
+}
+
+// hashLegacyKeys replaces the plaintext key column of licenses created before keys were hashed
+// with the key's hash and hint. It runs before AutoMigrate, which cannot add the NOT NULL hash column
+// to a table that has rows, and does nothing once the key column is gone. It returns the number of licenses hashed.
+func hashLegacyKeys(db *gorm.DB) (int, error) {
+	if !db.Migrator().HasTable(&License{}) {
+		return 0, nil
+	}
+	// HasColumn matches the column name anywhere in the table definition, "PRIMARY KEY" included
+	columnTypes, err := db.Migrator().ColumnTypes(&License{})
+	if err != nil {
+		return 0, err
+	}
+	columns := make(map[string]bool, len(columnTypes))
+	for _, columnType := range columnTypes {
+		columns[columnType.Name()] = true
+	}
+	if !columns["key"] {
+		return 0, nil
+	}
+
+	hashed := 0
+	err = db.Transaction(func(tx *gorm.DB) error {
+		stmt := &gorm.Statement{DB: tx}
+		if err := stmt.Parse(&License{}); err != nil {
+			return err
+		}
+		table := stmt.Schema.Table
+
+		for _, column := range []string{"key_hash", "key_hint"} {
+			if !columns[column] {
+				if err := tx.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " text").Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		var rows []struct {
+			ID  uint
+			Key string
+		}
+		// Deleted licenses keep their key in the unique index, so they are hashed as well
+		if err := tx.Table(table).Select("id, key").Scan(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			err := tx.Table(table).Where("id = ?", row.ID).Updates(map[string]interface{}{
+				"key_hash": utils.HashLicenseKey(row.Key),
+				"key_hint": utils.LicenseKeyHint(row.Key),
+			}).Error
+			if err != nil {
+				return err
+			}
+			hashed++
+		}
+
+		if tx.Migrator().HasIndex(&License{}, "idx_application_key") {
+			if err := tx.Migrator().DropIndex(&License{}, "idx_application_key"); err != nil {
+				return err
+			}
+		}
+		// The migrator's DropColumn rewrites the table definition and does not match every legacy schema
+		return tx.Exec("ALTER TABLE " + table + " DROP COLUMN `key`").Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return hashed, nil
+}
+
+// migrateLegacyTimestamps converts formatted timestamp strings to UTC time values and placeholders to NULL.
+// Converted rows no longer match, so running it again is a no-op.
+func migrateLegacyTimestamps(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range legacyTimestampColumns {
+			stmt := &gorm.Statement{DB: tx}
+			if err := stmt.Parse(table.model); err != nil {
+				return err
+			}
+			for _, column := range table.columns {
+				if err := migrateLegacyTimestampColumn(tx, stmt.Schema.Table, column); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func migrateLegacyTimestampColumn(tx *gorm.DB, table string, column string) error {
+	var rows []struct {
+		ID    uint
+		Value string
+	}
+
+	// Cast to text so the driver does not try to parse the value as a time itself
+	err := tx.Table(table).
+		Select("id, CAST("+column+" AS TEXT) AS value").
+		Where(column+" IN ? OR "+column+" LIKE ?", legacyTimestampPlaceholders, "% @ %").
+		Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		var value interface{}
+		if t, err := time.ParseInLocation(utils.LegacyDatetimeLayout, row.Value, time.Local); err == nil {
+			value = t.UTC()
+		}
+		if err := tx.Table(table).Where("id = ?", row.ID).Update(column, value).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backfillActivations creates an activation for licenses redeemed before activations were tracked
+func backfillActivations(db *gorm.DB) error {
+	var licenses []License
+	err := db.Where("status = ? AND hw_id <> ? AND id NOT IN (?)", LicenseActive, "N/A", db.Model(&Activation{}).Select("license_id")).Find(&licenses).Error
+	if err != nil {
+		return err
+	}
+
+	for _, license := range licenses {
+		var firstSeenOn time.Time
+		if license.UsedOn != nil {
+			firstSeenOn = *license.UsedOn
+		}
+		lastSeenOn := firstSeenOn
+		if license.LastSeenOn != nil {
+			lastSeenOn = *license.LastSeenOn
+		}
+
+		activation := Activation{
+			LicenseID:     license.ID,
+			ApplicationID: license.ApplicationID,
+			HWID:          license.HWID,
+			IP:            license.IP,
+			FirstSeenOn:   firstSeenOn,
+			LastSeenOn:    lastSeenOn,
+		}
+		if err := db.Create(&activation).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
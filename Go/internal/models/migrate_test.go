@@ -0,0 +1,177 @@
+package models
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"backend/internal/utils"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	// The pepper is read once, so it has to be set before the first key is hashed
+	os.Setenv("LICENSE_KEY_PEPPER", "migrate-test-pepper-0123456789abcdef")
+	os.Exit(m.Run())
+}
+
+// legacyLicense is the license table as it was before keys were hashed and timestamps stored as time values
+type legacyLicense struct {
+	gorm.Model
+	UserID        string `gorm:"index;size:36"`
+	ApplicationID string `gorm:"size:36;not null;uniqueIndex:idx_application_key"`
+	Key           string `gorm:"size:100;not null;uniqueIndex:idx_application_key"`
+	Note          string `gorm:"size:255"`
+	CreatedOn     string `gorm:"size:50"`
+	Duration      string `gorm:"size:50"`
+	GeneratedBy   string `gorm:"size:50"`
+	UsedOn        string `gorm:"size:50"`
+	ExpiresOn     string `gorm:"size:50"`
+	Status        string `gorm:"size:50"`
+	IP            string `gorm:"size:45"`
+	HWID          string `gorm:"size:255"`
+}
+
+func (legacyLicense) TableName() string {
+	return "licenses"
+}
+
+func openLegacyDB(t *testing.T, licenses ...legacyLicense) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(t.TempDir()+"/legacy.db"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&legacyLicense{}); err != nil {
+		t.Fatalf("create legacy schema: %v", err)
+	}
+	for i := range licenses {
+		if err := db.Create(&licenses[i]).Error; err != nil {
+			t.Fatalf("create legacy license: %v", err)
+		}
+	}
+	return db
+}
+
+func TestMigrateHashesLegacyKeys(t *testing.T) {
+	db := openLegacyDB(t,
+		legacyLicense{ApplicationID: "app", Key: "AAAA-BBBB-CCCC-1111", Status: "Not Used", HWID: "N/A"},
+		legacyLicense{ApplicationID: "app", Key: "AAAA-BBBB-CCCC-2222", Status: "Not Used", HWID: "N/A"},
+	)
+	// Deleted licenses keep their row, so their key has to be hashed as well
+	if err := db.Where("key = ?", "AAAA-BBBB-CCCC-2222").Delete(&legacyLicense{}).Error; err != nil {
+		t.Fatalf("delete legacy license: %v", err)
+	}
+
+	result, err := Migrate(db)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if result.HashedKeys != 2 {
+		t.Errorf("HashedKeys = %d, want 2", result.HashedKeys)
+	}
+
+	var licenses []License
+	if err := db.Unscoped().Order("id").Find(&licenses).Error; err != nil {
+		t.Fatalf("load licenses: %v", err)
+	}
+	if len(licenses) != 2 {
+		t.Fatalf("got %d licenses, want 2", len(licenses))
+	}
+	for i, key := range []string{"AAAA-BBBB-CCCC-1111", "AAAA-BBBB-CCCC-2222"} {
+		if licenses[i].KeyHash != utils.HashLicenseKey(key) {
+			t.Errorf("license %d: KeyHash = %q, want the hash of %q", licenses[i].ID, licenses[i].KeyHash, key)
+		}
+		if licenses[i].KeyHint != utils.LicenseKeyHint(key) {
+			t.Errorf("license %d: KeyHint = %q, want %q", licenses[i].ID, licenses[i].KeyHint, utils.LicenseKeyHint(key))
+		}
+	}
+	if !licenses[1].DeletedAt.Valid {
+		t.Errorf("license %d: lost its deletion", licenses[1].ID)
+	}
+
+	columnTypes, err := db.Migrator().ColumnTypes(&License{})
+	if err != nil {
+		t.Fatalf("read columns: %v", err)
+	}
+	for _, columnType := range columnTypes {
+		if columnType.Name() == "key" {
+			t.Errorf("plaintext key column was not dropped")
+		}
+	}
+
+	// A second run finds no key column and leaves the hashes alone
+	result, err = Migrate(db)
+	if err != nil {
+		t.Fatalf("second Migrate: %v", err)
+	}
+	if result.HashedKeys != 0 {
+		t.Errorf("second run: HashedKeys = %d, want 0", result.HashedKeys)
+	}
+	var again []License
+	if err := db.Unscoped().Order("id").Find(&again).Error; err != nil {
+		t.Fatalf("load licenses: %v", err)
+	}
+	for i := range again {
+		if again[i].KeyHash != licenses[i].KeyHash || again[i].KeyHint != licenses[i].KeyHint {
+			t.Errorf("license %d: second run changed the key hash or hint", again[i].ID)
+		}
+	}
+}
+
+func TestMigrateConvertsLegacyTimestamps(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC+5", 5*60*60)
+	defer func() { time.Local = local }()
+
+	db := openLegacyDB(t,
+		legacyLicense{ApplicationID: "app", Key: "KEY-1", Status: "Used", HWID: "hwid", CreatedOn: "2024-03-01 @ 09:30 AM", UsedOn: "2024-03-02 @ 02:15 PM", ExpiresOn: "N/A"},
+		legacyLicense{ApplicationID: "app", Key: "KEY-2", Status: "Not Used", HWID: "N/A", CreatedOn: "2024-03-01 @ 12:00 AM", UsedOn: "Never", ExpiresOn: ""},
+	)
+
+	if _, err := Migrate(db); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	var licenses []License
+	if err := db.Order("id").Find(&licenses).Error; err != nil {
+		t.Fatalf("load licenses: %v", err)
+	}
+	if len(licenses) != 2 {
+		t.Fatalf("got %d licenses, want 2", len(licenses))
+	}
+
+	// Legacy strings were server-local time
+	checkTime := func(name string, got *time.Time, want time.Time) {
+		t.Helper()
+		if got == nil {
+			t.Errorf("%s is NULL, want %v", name, want)
+		} else if !got.Equal(want) {
+			t.Errorf("%s = %v, want %v", name, got.UTC(), want)
+		}
+	}
+	checkTime("created_on", licenses[0].CreatedOn, time.Date(2024, 3, 1, 4, 30, 0, 0, time.UTC))
+	checkTime("used_on", licenses[0].UsedOn, time.Date(2024, 3, 2, 9, 15, 0, 0, time.UTC))
+	checkTime("created_on", licenses[1].CreatedOn, time.Date(2024, 2, 29, 19, 0, 0, 0, time.UTC))
+
+	// N/A, Never and the empty string all become NULL
+	for _, column := range []struct {
+		id     uint
+		column string
+	}{
+		{licenses[0].ID, "expires_on"},
+		{licenses[1].ID, "used_on"},
+		{licenses[1].ID, "expires_on"},
+	} {
+		var isNull bool
+		if err := db.Raw("SELECT "+column.column+" IS NULL FROM licenses WHERE id = ?", column.id).Scan(&isNull).Error; err != nil {
+			t.Fatalf("read %s: %v", column.column, err)
+		}
+		if !isNull {
+			t.Errorf("license %d: %s was not set to NULL", column.id, column.column)
+		}
+	}
+}
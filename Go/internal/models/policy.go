@@ -0,0 +1,95 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"backend/internal/utils"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// ExpiryStart tells when the duration of a license starts counting
+type ExpiryStart string
+
+const (
+	ExpiryStartActivation ExpiryStart = "activation" // At the first redemption, the default
+	ExpiryStartCreation   ExpiryStart = "creation"   // When the license is generated
+)
+
+// maxAllowedClientVersions bounds the client versions an application can allow
+const maxAllowedClientVersions = 50
+
+// maxAllowedNetworks bounds the networks an application or license can be restricted to
+const maxAllowedNetworks = 100
+
+var clientVersionPattern = regexp.MustCompile(`^[A-Za-z0-9._+-]*$`)
+
+// GracePeriod returns how long licenses keep working after they expire
+func (settings *ApplicationSettings) GracePeriod() time.Duration {
+	return time.Duration(settings.GracePeriodHours) * time.Hour
+}
+
+// ExpiryStartsAtCreation reports whether license durations count from when licenses are generated
+func (settings *ApplicationSettings) ExpiryStartsAtCreation() bool {
+	return settings.ExpiryStart == ExpiryStartCreation
+}
+
+// ClientVersionAllowed reports whether a client version may redeem licenses.
+// Every version is allowed when the application lists none, and an entry ending in * matches any version it prefixes.
+func (settings *ApplicationSettings) ClientVersionAllowed(version string) bool {
+	if len(settings.AllowedClientVersions) == 0 {
+		return true
+	}
+	if version == "" {
+		return false
+	}
+	for _, allowed := range settings.AllowedClientVersions {
+		if prefix, wildcard := strings.CutSuffix(allowed, "*"); wildcard && strings.HasPrefix(version, prefix) {
+			return true
+		} else if allowed == version {
+			return true
+		}
+	}
+	return false
+}
+
+// IPLockPrefixLengths returns the prefix lengths of the subnets IPv4 and IPv6 locks cover
+func (settings *ApplicationSettings) IPLockPrefixLengths() (int, int) {
+	ipv4Bits, ipv6Bits := settings.IPLockIPv4PrefixLength, settings.IPLockIPv6PrefixLength
+	if ipv4Bits == 0 {
+		ipv4Bits = 32
+	}
+	if ipv6Bits == 0 {
+		ipv6Bits = 128
+	}
+	return ipv4Bits, ipv6Bits
+}
+
+// validateNetworks checks a list of IPs and CIDR ranges
+func validateNetworks(value interface{}) error {
+	networks, _ := validation.Indirect(value)
+	list, _ := networks.([]string)
+	if len(list) > maxAllowedNetworks {
+		return fmt.Errorf("at most %d networks are allowed", maxAllowedNetworks)
+	}
+	return utils.ValidateNetworks(list)
+}
+
+// validateClientVersions checks the client versions an application allows
+func validateClientVersions(value interface{}) error {
+	versions, _ := validation.Indirect(value)
+	list, _ := versions.([]string)
+	if len(list) > maxAllowedClientVersions {
+		return fmt.Errorf("at most %d client versions are allowed", maxAllowedClientVersions)
+	}
+	for _, version := range list {
+		body, _ := strings.CutSuffix(version, "*")
+		if version == "" || len(version) > 50 || !clientVersionPattern.MatchString(body) {
+			return fmt.Errorf("invalid client version %q", version)
+		}
+	}
+	return nil
+}
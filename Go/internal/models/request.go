@@ -3,6 +3,8 @@ package models
 import (
 	"regexp"
 
+	"backend/internal/utils"
+
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/microcosm-cc/bluemonday"
 )
@@ -32,53 +34,409 @@ func (createApplicationRequest *CreateApplicationRequest) Validate() error {
 	)
 }
 
+// RenameApplicationRequest is the JSON request body for renaming an application
+type RenameApplicationRequest struct {
+	AppName string `json:"appName" binding:"required"`
+}
+
+// Input validation method for RenameApplicationRequest
+func (renameApplicationRequest *RenameApplicationRequest) Validate() error {
+	renameApplicationRequest.AppName = sanitizeInput(renameApplicationRequest.AppName)
+
+	return validation.ValidateStruct(renameApplicationRequest,
+		validation.Field(&renameApplicationRequest.AppName, validation.Required, validation.RuneLength(1, 25)),
+	)
+}
+
+// DefaultClientSecretTransitionHours is how long a rotated client secret stays valid when no transition is given
+const DefaultClientSecretTransitionHours = 24
+
+// RotateClientSecretRequest is the JSON request body for replacing the client secret of an application
+type RotateClientSecretRequest struct {
+	TransitionHours *int `json:"transition_hours"` // How long the replaced secret stays valid, 24 when omitted and 0 to revoke it at once
+}
+
+// Input validation method for RotateClientSecretRequest
+func (rotateClientSecretRequest *RotateClientSecretRequest) Validate() error {
+	return validation.ValidateStruct(rotateClientSecretRequest,
+		validation.Field(&rotateClientSecretRequest.TransitionHours, validation.Min(0), validation.Max(720)),
+	)
+}
+
+// UpdateApplicationSettingsRequest is the JSON request body for updating application settings, omitted fields are left unchanged
+type UpdateApplicationSettingsRequest struct {
+	HWIDResetEnabled       *bool                    `json:"hwid_reset_enabled"`
+	HWIDResetLimit         *int                     `json:"hwid_reset_limit"`
+	HWIDResetPeriodDays    *int                     `json:"hwid_reset_period_days"`
+	HWIDResetCooldownHours *int                     `json:"hwid_reset_cooldown_hours"`
+	KeyAlphabet            *string                  `json:"key_alphabet"`
+	KeyMask                *string                  `json:"key_mask"` // Empty string removes the declared key format
+	KeyChecksum            *bool                    `json:"key_checksum"`
+	RequireKeyChecksum     *bool                    `json:"require_key_checksum"`
+	Entitlements           *[]EntitlementDefinition `json:"entitlements"`        // Replaces the declared entitlements, licenses keep the values they have
+	SessionTTLSeconds      *int                     `json:"session_ttl_seconds"` // 0 restores the default
+	TrialEnabled           *bool                    `json:"trial_enabled"`
+	TrialDurationDays      *int                     `json:"trial_duration_days"` // 0 restores the default
+	HWIDLockDisabled       *bool                    `json:"hwid_lock_disabled"`
+	IPLock                 *bool                    `json:"ip_lock"`
+	ExpiryStart            *ExpiryStart             `json:"expiry_start"` // activation or creation, only applies to licenses redeemed afterwards
+	GracePeriodHours       *int                     `json:"grace_period_hours"`
+	AllowedClientVersions  *[]string                `json:"allowed_client_versions"` // Empty list allows any version, a trailing * matches any suffix
+	AllowedNetworks        *[]string                `json:"allowed_networks"`        // Empty list allows any IP
+	IPLockIPv4PrefixLength *int                     `json:"ip_lock_ipv4_prefix_length"`
+	IPLockIPv6PrefixLength *int                     `json:"ip_lock_ipv6_prefix_length"`
+	AutoBlacklistOnBan     *bool                    `json:"auto_blacklist_on_ban"`
+	RequireSignedRequests  *bool                    `json:"require_signed_requests"`
+}
+
+// Input validation method for UpdateApplicationSettingsRequest
+func (updateApplicationSettingsRequest *UpdateApplicationSettingsRequest) Validate() error {
+	if updateApplicationSettingsRequest.KeyMask != nil {
+		*updateApplicationSettingsRequest.KeyMask = sanitizeInput(*updateApplicationSettingsRequest.KeyMask)
+	}
+
+	return validation.ValidateStruct(updateApplicationSettingsRequest,
+		validation.Field(&updateApplicationSettingsRequest.HWIDResetLimit, validation.Min(0), validation.Max(100)),
+		validation.Field(&updateApplicationSettingsRequest.HWIDResetPeriodDays, validation.Min(0), validation.Max(365)),
+		validation.Field(&updateApplicationSettingsRequest.HWIDResetCooldownHours, validation.Min(0), validation.Max(8760)),
+		validation.Field(&updateApplicationSettingsRequest.KeyAlphabet, validation.In(utils.KeyAlphabetDefault, utils.KeyAlphabetCrockford)),
+		validation.Field(&updateApplicationSettingsRequest.KeyMask, validation.By(validateKeyMask)),
+		validation.Field(&updateApplicationSettingsRequest.Entitlements, validation.By(validateEntitlementDefinitions)),
+		validation.Field(&updateApplicationSettingsRequest.SessionTTLSeconds, validation.When(updateApplicationSettingsRequest.SessionTTLSeconds != nil && *updateApplicationSettingsRequest.SessionTTLSeconds != 0, validation.Min(30), validation.Max(86400))),
+		validation.Field(&updateApplicationSettingsRequest.TrialDurationDays, validation.Min(0), validation.Max(90)),
+		validation.Field(&updateApplicationSettingsRequest.ExpiryStart, validation.In(ExpiryStartActivation, ExpiryStartCreation)),
+		validation.Field(&updateApplicationSettingsRequest.GracePeriodHours, validation.Min(0), validation.Max(720)),
+		validation.Field(&updateApplicationSettingsRequest.AllowedClientVersions, validation.By(validateClientVersions)),
+		validation.Field(&updateApplicationSettingsRequest.AllowedNetworks, validation.By(validateNetworks)),
+		validation.Field(&updateApplicationSettingsRequest.IPLockIPv4PrefixLength, validation.Min(0), validation.Max(32)),
+		validation.Field(&updateApplicationSettingsRequest.IPLockIPv6PrefixLength, validation.Min(0), validation.Max(128)),
+	)
+}
+
+// Apply copies the provided fields onto the application settings
+func (updateApplicationSettingsRequest *UpdateApplicationSettingsRequest) Apply(settings *ApplicationSettings) {
+	if updateApplicationSettingsRequest.HWIDResetEnabled != nil {
+		settings.HWIDResetEnabled = *updateApplicationSettingsRequest.HWIDResetEnabled
+	}
+	if updateApplicationSettingsRequest.HWIDResetLimit != nil {
+		settings.HWIDResetLimit = *updateApplicationSettingsRequest.HWIDResetLimit
+	}
+	if updateApplicationSettingsRequest.HWIDResetPeriodDays != nil {
+		settings.HWIDResetPeriodDays = *updateApplicationSettingsRequest.HWIDResetPeriodDays
+	}
+	if updateApplicationSettingsRequest.HWIDResetCooldownHours != nil {
+		settings.HWIDResetCooldownHours = *updateApplicationSettingsRequest.HWIDResetCooldownHours
+	}
+	if updateApplicationSettingsRequest.KeyAlphabet != nil {
+		settings.KeyAlphabet = *updateApplicationSettingsRequest.KeyAlphabet
+	}
+	if updateApplicationSettingsRequest.KeyMask != nil {
+		settings.KeyMask = *updateApplicationSettingsRequest.KeyMask
+	}
+	if updateApplicationSettingsRequest.KeyChecksum != nil {
+		settings.KeyChecksum = *updateApplicationSettingsRequest.KeyChecksum
+	}
+	if updateApplicationSettingsRequest.RequireKeyChecksum != nil {
+		settings.RequireKeyChecksum = *updateApplicationSettingsRequest.RequireKeyChecksum
+	}
+	if updateApplicationSettingsRequest.Entitlements != nil {
+		settings.Entitlements = *updateApplicationSettingsRequest.Entitlements
+	}
+	if updateApplicationSettingsRequest.SessionTTLSeconds != nil {
+		settings.SessionTTLSeconds = *updateApplicationSettingsRequest.SessionTTLSeconds
+	}
+	if updateApplicationSettingsRequest.TrialEnabled != nil {
+		settings.TrialEnabled = *updateApplicationSettingsRequest.TrialEnabled
+	}
+	if updateApplicationSettingsRequest.TrialDurationDays != nil {
+		settings.TrialDurationDays = *updateApplicationSettingsRequest.TrialDurationDays
+	}
+	if updateApplicationSettingsRequest.HWIDLockDisabled != nil {
+		settings.HWIDLockDisabled = *updateApplicationSettingsRequest.HWIDLockDisabled
+	}
+	if updateApplicationSettingsRequest.IPLock != nil {
+		settings.IPLock = *updateApplicationSettingsRequest.IPLock
+	}
+	if updateApplicationSettingsRequest.ExpiryStart != nil {
+		settings.ExpiryStart = *updateApplicationSettingsRequest.ExpiryStart
+	}
+	if updateApplicationSettingsRequest.GracePeriodHours != nil {
+		settings.GracePeriodHours = *updateApplicationSettingsRequest.GracePeriodHours
+	}
+	if updateApplicationSettingsRequest.AllowedClientVersions != nil {
+		settings.AllowedClientVersions = *updateApplicationSettingsRequest.AllowedClientVersions
+	}
+	if updateApplicationSettingsRequest.AllowedNetworks != nil {
+		settings.AllowedNetworks = *updateApplicationSettingsRequest.AllowedNetworks
+	}
+	if updateApplicationSettingsRequest.IPLockIPv4PrefixLength != nil {
+		settings.IPLockIPv4PrefixLength = *updateApplicationSettingsRequest.IPLockIPv4PrefixLength
+	}
+	if updateApplicationSettingsRequest.IPLockIPv6PrefixLength != nil {
+		settings.IPLockIPv6PrefixLength = *updateApplicationSettingsRequest.IPLockIPv6PrefixLength
+	}
+	if updateApplicationSettingsRequest.AutoBlacklistOnBan != nil {
+		settings.AutoBlacklistOnBan = *updateApplicationSettingsRequest.AutoBlacklistOnBan
+	}
+	if updateApplicationSettingsRequest.RequireSignedRequests != nil {
+		settings.RequireSignedRequests = *updateApplicationSettingsRequest.RequireSignedRequests
+	}
+}
+
 // LicenseRequest is the JSON request body for creating a license
 type LicenseRequest struct {
-	LicenseAmount     int    `json:"license_amount"`
-	LicenseMask       string `json:"license_mask"`
-	Prefix            string `json:"prefix"`
-	LicenseNote       string `json:"license_note"`
-	LicenseExpiryUnit string `json:"license_expiry_unit"`
-	LicenseDuration   int    `json:"license_duration"`
+	LicenseAmount     int          `json:"license_amount"`
+	LicenseMask       string       `json:"license_mask"` // Optional when the application declares a key mask
+	Prefix            string       `json:"prefix"`
+	LicenseNote       string       `json:"license_note"`
+	LicenseExpiryUnit string       `json:"license_expiry_unit"`
+	LicenseDuration   int          `json:"license_duration"`
+	MaxActivations    int          `json:"max_activations"`
+	MaxSessions       int          `json:"max_sessions"` // Concurrent sessions, 0 for unlimited
+	UsageQuota        int64        `json:"usage_quota"`  // Units the license may consume, 0 for licenses that are not metered
+	Lifetime          bool         `json:"lifetime"`     // Never expires, license_duration and license_expiry_unit are ignored
+	Entitlements      Entitlements `json:"entitlements"` // Must be declared by the application
 }
 
 // Input validation method for LicenseRequest
 func (licenseRequest *LicenseRequest) Validate() error {
+	return licenseRequest.validate(100)
+}
+
+// MaxLicenseJobAmount is the most licenses a single generation job may create
+const MaxLicenseJobAmount = 100000
+
+// LicenseJobRequest is the JSON request body for generating licenses in the background, the same as LicenseRequest
+// with a higher license_amount limit
+type LicenseJobRequest LicenseRequest
+
+// Input validation method for LicenseJobRequest
+func (licenseJobRequest *LicenseJobRequest) Validate() error {
+	return (*LicenseRequest)(licenseJobRequest).validate(MaxLicenseJobAmount)
+}
+
+func (licenseRequest *LicenseRequest) validate(maxAmount int) error {
 	// add more sanitization here
 	licenseRequest.Prefix = sanitizeInput(licenseRequest.Prefix)
+	licenseRequest.LicenseMask = sanitizeInput(licenseRequest.LicenseMask)
 	licenseRequest.LicenseNote = sanitizeInput(licenseRequest.LicenseNote)
 	licenseRequest.LicenseExpiryUnit = sanitizeInput(licenseRequest.LicenseExpiryUnit)
 
 	return validation.ValidateStruct(licenseRequest,
-		validation.Field(&licenseRequest.LicenseAmount, validation.Required, validation.Min(1), validation.Max(100)),
-		validation.Field(&licenseRequest.LicenseMask, validation.Required, validation.Match(regexp.MustCompile(`^([X]+(-[X]+)*)?$`))),
+		validation.Field(&licenseRequest.LicenseAmount, validation.Required, validation.Min(1), validation.Max(maxAmount)),
+		validation.Field(&licenseRequest.LicenseMask, validation.By(validateKeyMask)),
 		validation.Field(&licenseRequest.Prefix, validation.Required, validation.Length(1, 25), validation.Match(regexp.MustCompile(`^[A-Za-z0-9]+$`))),
 		validation.Field(&licenseRequest.LicenseNote, validation.RuneLength(0, 255)),
-		validation.Field(&licenseRequest.LicenseExpiryUnit, validation.Required, validation.In("Day", "Days", "Week", "Weeks", "Month", "Months", "Year", "Years")),
-		validation.Field(&licenseRequest.LicenseDuration, validation.Required, validation.Min(1), validation.Max(10)),
+		validation.Field(&licenseRequest.LicenseExpiryUnit, validation.When(!licenseRequest.Lifetime, validation.Required, validation.In("Day", "Days", "Week", "Weeks", "Month", "Months", "Year", "Years"))),
+		validation.Field(&licenseRequest.LicenseDuration, validation.When(!licenseRequest.Lifetime, validation.Required, validation.Min(1), validation.Max(10))),
+		validation.Field(&licenseRequest.MaxActivations, validation.Min(0), validation.Max(1000)),
+		validation.Field(&licenseRequest.MaxSessions, validation.Min(0), validation.Max(1000)),
+		validation.Field(&licenseRequest.UsageQuota, validation.Min(int64(0)), validation.Max(MaxUsageQuota)),
+		validation.Field(&licenseRequest.Entitlements, validation.By(validateEntitlements)),
 	)
 }
 
 // RedeemLicenseRequest is the JSON request body for redeeming a license
 type RedeemLicenseRequest struct {
-	Key  string `json:"key" binding:"required"`
-	HWID string `json:"hwid" binding:"required"`
+	Key           string `json:"key" binding:"required"`
+	HWID          string `json:"hwid" binding:"required"`
+	ClientVersion string `json:"client_version"` // Required when the application restricts client versions
+	Nonce         string `json:"nonce"`          // Random value echoed in the signed token so the client can tell the response is fresh
 }
 
 // Input validation method for RedeemLicenseRequest
 func (redeemLicenseRequest *RedeemLicenseRequest) Validate() error {
 	redeemLicenseRequest.Key = sanitizeInput(redeemLicenseRequest.Key)
 	redeemLicenseRequest.HWID = sanitizeInput(redeemLicenseRequest.HWID)
+	redeemLicenseRequest.ClientVersion = sanitizeInput(redeemLicenseRequest.ClientVersion)
+	redeemLicenseRequest.Nonce = sanitizeInput(redeemLicenseRequest.Nonce)
 
 	return validation.ValidateStruct(redeemLicenseRequest,
 		validation.Field(&redeemLicenseRequest.Key, validation.Required, validation.Length(1, 100), validation.Match(regexp.MustCompile(`^[A-Za-z0-9-]+$`))),
 		validation.Field(&redeemLicenseRequest.HWID, validation.Required, validation.Length(1, 255)),
+		validation.Field(&redeemLicenseRequest.ClientVersion, validation.Length(0, 50), validation.Match(clientVersionPattern)),
+		validation.Field(&redeemLicenseRequest.Nonce, validation.Length(16, 128), validation.Match(regexp.MustCompile(`^[A-Za-z0-9_+/=-]+$`))),
+	)
+}
+
+// ValidateLicenseRequest is the JSON request body for validating a license or sending a heartbeat
+type ValidateLicenseRequest struct {
+	Key   string `json:"key" binding:"required"`
+	HWID  string `json:"hwid" binding:"required"`
+	Nonce string `json:"nonce"` // Random value echoed in the signed token of a validation, ignored by heartbeats and sessions
+}
+
+// Input validation method for ValidateLicenseRequest
+func (validateLicenseRequest *ValidateLicenseRequest) Validate() error {
+	validateLicenseRequest.Key = sanitizeInput(validateLicenseRequest.Key)
+	validateLicenseRequest.HWID = sanitizeInput(validateLicenseRequest.HWID)
+	validateLicenseRequest.Nonce = sanitizeInput(validateLicenseRequest.Nonce)
+
+	return validation.ValidateStruct(validateLicenseRequest,
+		validation.Field(&validateLicenseRequest.Key, validation.Required, validation.Length(1, 100), validation.Match(regexp.MustCompile(`^[A-Za-z0-9-]+$`))),
+		validation.Field(&validateLicenseRequest.HWID, validation.Required, validation.Length(1, 255)),
+		validation.Field(&validateLicenseRequest.Nonce, validation.Length(16, 128), validation.Match(regexp.MustCompile(`^[A-Za-z0-9_+/=-]+$`))),
+	)
+}
+
+// TrialRequest is the JSON request body for requesting a trial license
+type TrialRequest struct {
+	HWID string `json:"hwid" binding:"required"`
+}
+
+// Input validation method for TrialRequest
+func (trialRequest *TrialRequest) Validate() error {
+	trialRequest.HWID = sanitizeInput(trialRequest.HWID)
+
+	return validation.ValidateStruct(trialRequest,
+		validation.Field(&trialRequest.HWID, validation.Required, validation.Length(1, 255)),
+	)
+}
+
+// ConvertTrialRequest is the JSON request body for converting a trial license into a paid one
+type ConvertTrialRequest struct {
+	LicenseMask       string       `json:"license_mask"` // Optional when the application declares a key mask
+	Prefix            string       `json:"prefix"`
+	LicenseExpiryUnit string       `json:"license_expiry_unit"`
+	LicenseDuration   int          `json:"license_duration"`
+	Lifetime          bool         `json:"lifetime"`
+	Entitlements      Entitlements `json:"entitlements"`
+}
+
+// Input validation method for ConvertTrialRequest
+func (convertTrialRequest *ConvertTrialRequest) Validate() error {
+	convertTrialRequest.LicenseMask = sanitizeInput(convertTrialRequest.LicenseMask)
+	convertTrialRequest.Prefix = sanitizeInput(convertTrialRequest.Prefix)
+	convertTrialRequest.LicenseExpiryUnit = sanitizeInput(convertTrialRequest.LicenseExpiryUnit)
+
+	// The rules for generating a single license apply
+	return convertTrialRequest.LicenseRequest().Validate()
+}
+
+// LicenseRequest returns the request for generating the paid key, keeping the seat and session limits of the license
+func (convertTrialRequest *ConvertTrialRequest) LicenseRequest() *LicenseRequest {
+	return &LicenseRequest{
+		LicenseAmount:     1,
+		LicenseMask:       convertTrialRequest.LicenseMask,
+		Prefix:            convertTrialRequest.Prefix,
+		LicenseExpiryUnit: convertTrialRequest.LicenseExpiryUnit,
+		LicenseDuration:   convertTrialRequest.LicenseDuration,
+		Lifetime:          convertTrialRequest.Lifetime,
+		Entitlements:      convertTrialRequest.Entitlements,
+	}
+}
+
+// SessionRequest is the JSON request body for renewing or checking in a session
+type SessionRequest struct {
+	Key       string `json:"key" binding:"required"`
+	SessionID string `json:"session_id" binding:"required"`
+}
+
+// Input validation method for SessionRequest
+func (sessionRequest *SessionRequest) Validate() error {
+	sessionRequest.Key = sanitizeInput(sessionRequest.Key)
+	sessionRequest.SessionID = sanitizeInput(sessionRequest.SessionID)
+
+	return validation.ValidateStruct(sessionRequest,
+		validation.Field(&sessionRequest.Key, validation.Required, validation.Length(1, 100), validation.Match(regexp.MustCompile(`^[A-Za-z0-9-]+$`))),
+		validation.Field(&sessionRequest.SessionID, validation.Required, validation.Length(36, 36), validation.Match(regexp.MustCompile("^[a-fA-F0-9-]{36}$"))),
+	)
+}
+
+// UpdateLicenseNetworksRequest is the JSON request body for restricting the IPs a license may be used from
+type UpdateLicenseNetworksRequest struct {
+	AllowedNetworks []string `json:"allowed_networks"` // IPv4 and IPv6 addresses and CIDR ranges, empty to lift the restriction
+}
+
+// Input validation method for UpdateLicenseNetworksRequest
+func (updateLicenseNetworksRequest *UpdateLicenseNetworksRequest) Validate() error {
+	return validation.ValidateStruct(updateLicenseNetworksRequest,
+		validation.Field(&updateLicenseNetworksRequest.AllowedNetworks, validation.By(validateNetworks)),
+	)
+}
+
+// BlacklistEntryRequest is the JSON request body for blacklisting a HWID or IP
+type BlacklistEntryRequest struct {
+	Kind   BlacklistKind `json:"kind" binding:"required"`  // hwid or ip
+	Value  string        `json:"value" binding:"required"` // A HWID, or an IPv4 or IPv6 address or CIDR range
+	Reason string        `json:"reason"`
+}
+
+// Input validation method for BlacklistEntryRequest
+func (blacklistEntryRequest *BlacklistEntryRequest) Validate() error {
+	blacklistEntryRequest.Value = sanitizeInput(blacklistEntryRequest.Value)
+	blacklistEntryRequest.Reason = sanitizeInput(blacklistEntryRequest.Reason)
+
+	return validation.ValidateStruct(blacklistEntryRequest,
+		validation.Field(&blacklistEntryRequest.Kind, validation.Required, validation.In(BlacklistHWID, BlacklistIP)),
+		validation.Field(&blacklistEntryRequest.Value, validation.Required, validation.Length(1, 255),
+			validation.When(blacklistEntryRequest.Kind == BlacklistIP, validation.By(func(value interface{}) error {
+				return utils.ValidateNetworks([]string{value.(string)})
+			})),
+		),
+		validation.Field(&blacklistEntryRequest.Reason, validation.RuneLength(0, 255)),
+	)
+}
+
+// MaxUsageQuota is the largest usage quota a metered license may have
+const MaxUsageQuota = int64(1000000000)
+
+// ConsumeUsageRequest is the JSON request body for consuming units of a metered license
+type ConsumeUsageRequest struct {
+	Key   string `json:"key" binding:"required"`
+	HWID  string `json:"hwid" binding:"required"`
+	Units int64  `json:"units"` // Defaults to 1
+}
+
+// Input validation method for ConsumeUsageRequest
+func (consumeUsageRequest *ConsumeUsageRequest) Validate() error {
+	consumeUsageRequest.Key = sanitizeInput(consumeUsageRequest.Key)
+	consumeUsageRequest.HWID = sanitizeInput(consumeUsageRequest.HWID)
+	if consumeUsageRequest.Units == 0 {
+		consumeUsageRequest.Units = 1
+	}
+
+	return validation.ValidateStruct(consumeUsageRequest,
+		validation.Field(&consumeUsageRequest.Key, validation.Required, validation.Length(1, 100), validation.Match(regexp.MustCompile(`^[A-Za-z0-9-]+$`))),
+		validation.Field(&consumeUsageRequest.HWID, validation.Required, validation.Length(1, 255)),
+		validation.Field(&consumeUsageRequest.Units, validation.Min(int64(1)), validation.Max(MaxUsageQuota)),
+	)
+}
+
+// UpdateLicenseUsageRequest is the JSON request body for changing the quota of a metered license,
+// fields that are left out are not changed
+type UpdateLicenseUsageRequest struct {
+	UsageQuota *int64 `json:"usage_quota"` // 0 stops metering the license
+	ResetUsage bool   `json:"reset_usage"` // Sets the consumed units back to 0
+}
+
+// Input validation method for UpdateLicenseUsageRequest
+func (updateLicenseUsageRequest *UpdateLicenseUsageRequest) Validate() error {
+	return validation.ValidateStruct(updateLicenseUsageRequest,
+		validation.Field(&updateLicenseUsageRequest.UsageQuota, validation.Min(int64(0)), validation.Max(MaxUsageQuota)),
+	)
+}
+
+// ResetHWIDRequest is the JSON request body for a customer resetting the HWID bound to their license
+type ResetHWIDRequest struct {
+	Key  string `json:"key" binding:"required"`
+	HWID string `json:"hwid" binding:"required"`
+}
+
+// Input validation method for ResetHWIDRequest
+func (resetHWIDRequest *ResetHWIDRequest) Validate() error {
+	resetHWIDRequest.Key = sanitizeInput(resetHWIDRequest.Key)
+	resetHWIDRequest.HWID = sanitizeInput(resetHWIDRequest.HWID)
+
+	return validation.ValidateStruct(resetHWIDRequest,
+		validation.Field(&resetHWIDRequest.Key, validation.Required, validation.Length(1, 100), validation.Match(regexp.MustCompile(`^[A-Za-z0-9-]+$`))),
+		validation.Field(&resetHWIDRequest.HWID, validation.Required, validation.Length(1, 255)),
 	)
 }
 
 // DeleteLicensesRequest is the JSON request body for deleting multiple licenses
 type DeleteLicensesRequest struct {
-	Keys []string `json:"keys" binding:"required"`
+	Keys []string `json:"keys" binding:"required"` // License keys or IDs
 }
 
 // Input validation method for DeleteLicensesRequest
@@ -99,20 +457,99 @@ func (deleteLicensesRequest *DeleteLicensesRequest) Validate() error {
 	)
 }
 
+// UpdateLicenseEntitlementsRequest is the JSON request body for replacing the entitlements of a license
+type UpdateLicenseEntitlementsRequest struct {
+	Entitlements Entitlements `json:"entitlements"` // Empty removes every entitlement
+}
+
+// Input validation method for UpdateLicenseEntitlementsRequest
+func (updateLicenseEntitlementsRequest *UpdateLicenseEntitlementsRequest) Validate() error {
+	return validation.ValidateStruct(updateLicenseEntitlementsRequest,
+		validation.Field(&updateLicenseEntitlementsRequest.Entitlements, validation.By(validateEntitlements)),
+	)
+}
+
 // BanLicenseRequest is the JSON request body for banning a license
 type BanLicenseRequest struct {
-	Key string `json:"key" binding:"required"`
+	Key           string `json:"key" binding:"required"` // License key or ID
+	Reason        string `json:"reason"`
+	BanDuration   int    `json:"ban_duration"`    // Omit for a permanent ban
+	BanExpiryUnit string `json:"ban_expiry_unit"` // Required with ban_duration
 }
 
 // Input validation method for BanLicenseRequest
 func (banLicenseRequest *BanLicenseRequest) Validate() error {
 	banLicenseRequest.Key = sanitizeInput(banLicenseRequest.Key)
+	banLicenseRequest.Reason = sanitizeInput(banLicenseRequest.Reason)
+	banLicenseRequest.BanExpiryUnit = sanitizeInput(banLicenseRequest.BanExpiryUnit)
 
 	return validation.ValidateStruct(banLicenseRequest,
 		validation.Field(&banLicenseRequest.Key, validation.Required, validation.Length(1, 100), validation.Match(regexp.MustCompile(`^[A-Za-z0-9-]+$`))),
+		validation.Field(&banLicenseRequest.Reason, validation.RuneLength(0, 255)),
+		validation.Field(&banLicenseRequest.BanDuration, validation.Min(0), validation.Max(1000)),
+		validation.Field(&banLicenseRequest.BanExpiryUnit,
+			validation.When(banLicenseRequest.BanDuration > 0, validation.Required),
+			validation.In("Hour", "Hours", "Day", "Days", "Week", "Weeks", "Month", "Months", "Year", "Years"),
+		),
+	)
+}
+
+// ExtendLicenseRequest is the JSON request body for adding time to a license
+type ExtendLicenseRequest struct {
+	Duration   int    `json:"duration" binding:"required"`
+	ExpiryUnit string `json:"expiry_unit" binding:"required"`
+}
+
+// Input validation method for ExtendLicenseRequest
+func (extendLicenseRequest *ExtendLicenseRequest) Validate() error {
+	extendLicenseRequest.ExpiryUnit = sanitizeInput(extendLicenseRequest.ExpiryUnit)
+
+	return validation.ValidateStruct(extendLicenseRequest,
+		validation.Field(&extendLicenseRequest.Duration, validation.Required, validation.Min(1), validation.Max(1000)),
+		validation.Field(&extendLicenseRequest.ExpiryUnit, validation.Required, validation.In("Hour", "Hours", "Day", "Days", "Week", "Weeks", "Month", "Months", "Year", "Years")),
+	)
+}
+
+// ExtendLicensesRequest is the JSON request body for adding time to multiple licenses
+type ExtendLicensesRequest struct {
+	Keys       []string `json:"keys" binding:"required"` // License keys or IDs
+	Duration   int      `json:"duration" binding:"required"`
+	ExpiryUnit string   `json:"expiry_unit" binding:"required"`
+}
+
+// Input validation method for ExtendLicensesRequest
+func (extendLicensesRequest *ExtendLicensesRequest) Validate() error {
+	for i, key := range extendLicensesRequest.Keys {
+		extendLicensesRequest.Keys[i] = sanitizeInput(key)
+	}
+	extendLicensesRequest.ExpiryUnit = sanitizeInput(extendLicensesRequest.ExpiryUnit)
+
+	return validation.ValidateStruct(extendLicensesRequest,
+		validation.Field(&extendLicensesRequest.Keys,
+			validation.Required,
+			validation.Length(1, 100),
+			validation.Each(
+				validation.Required,
+				validation.Length(1, 100),
+				validation.Match(regexp.MustCompile(`^[A-Za-z0-9-]+$`)),
+			),
+		),
+		validation.Field(&extendLicensesRequest.Duration, validation.Required, validation.Min(1), validation.Max(1000)),
+		validation.Field(&extendLicensesRequest.ExpiryUnit, validation.Required, validation.In("Hour", "Hours", "Day", "Days", "Week", "Weeks", "Month", "Months", "Year", "Years")),
 	)
 }
 
+// validateKeyMask checks that a license mask can be parsed, an empty mask is allowed
+func validateKeyMask(value interface{}) error {
+	value, _ = validation.Indirect(value)
+	mask, _ := value.(string)
+	if mask == "" {
+		return nil
+	}
+	_, err := utils.ParseKeyMask(mask, utils.KeyAlphabetDefault)
+	return err
+}
+
 // ValidateUUID validates if a given string is a valid UUID with a length of 36.
 func ValidateUUID(uuid string) error {
 	sanitizedUUID := sanitizeInput(uuid)
@@ -135,6 +572,17 @@ func ValidateLicenseID(licenseID string) error {
 	)
 }
 
+// ValidateNumericID validates if a given string is a positive numeric ID.
+func ValidateNumericID(id string) error {
+	sanitizedID := sanitizeInput(id)
+
+	return validation.Validate(sanitizedID,
+		validation.Required,
+		validation.Length(1, 20),
+		validation.Match(regexp.MustCompile("^[1-9][0-9]*$")),
+	)
+}
+
 // dev request below
 
 type UserRequest struct {
@@ -1,16 +1,31 @@
 package models
 
 type LicenseResponse struct {
-	Key         string `json:"key"`
-	Note        string `json:"note"`
-	CreatedOn   string `json:"created_on"`
-	Duration    string `json:"duration"`
-	GeneratedBy string `json:"generated_by"`
-	UsedOn      string `json:"used_on"`
-	ExpiresOn   string `json:"expires_on"`
-	Status      string `json:"status"`
-	IP          string `json:"ip"`
-	HWID        string `json:"hwid"`
+	ID              uint                 `json:"id"`
+	Key             string               `json:"key,omitempty"` // Only set when the license is generated
+	KeyHint         string               `json:"key_hint"`
+	KeyVersion      int                  `json:"key_version"`
+	Note            string               `json:"note"`
+	CreatedOn       *string              `json:"created_on"`
+	Duration        string               `json:"duration"`
+	GeneratedBy     string               `json:"generated_by"`
+	UsedOn          *string              `json:"used_on"`
+	ExpiresOn       *string              `json:"expires_on"`
+	LastSeenOn      *string              `json:"last_seen_on"`
+	Status          LicenseStatus        `json:"status"`
+	IP              string               `json:"ip"`
+	HWID            string               `json:"hwid"`
+	MaxActivations  int                  `json:"max_activations"`
+	MaxSessions     int                  `json:"max_sessions"`
+	UsageQuota      int64                `json:"usage_quota"`
+	UsageCount      int64                `json:"usage_count"`
+	Lifetime        bool                 `json:"lifetime"`
+	Trial           bool                 `json:"trial"`
+	BannedUntil     *string              `json:"banned_until,omitempty"`
+	FrozenOn        *string              `json:"frozen_on,omitempty"`
+	Bans            []LicenseBanResponse `json:"bans,omitempty"`
+	Entitlements    Entitlements         `json:"entitlements"`
+	AllowedNetworks []string             `json:"allowed_networks"`
 }
 
 type RedeemLicenseResponse struct {
@@ -25,3 +40,87 @@ type RedeemLicenseResponse struct {
 	IP          string `json:"ip"`
 	HWID        string `json:"hwid"`
 }
+
+type LicenseBanResponse struct {
+	Reason      string  `json:"reason"`
+	BannedBy    string  `json:"banned_by"`
+	BannedOn    *string `json:"banned_on"`
+	BannedUntil *string `json:"banned_until"`
+	UnbannedBy  string  `json:"unbanned_by"`
+	UnbannedOn  *string `json:"unbanned_on"`
+}
+
+type ExtendedLicenseResponse struct {
+	ID        uint          `json:"id"`
+	KeyHint   string        `json:"key_hint"`
+	Duration  string        `json:"duration"`
+	ExpiresOn *string       `json:"expires_on"`
+	Status    LicenseStatus `json:"status"`
+}
+
+type ActivationResponse struct {
+	ID          uint    `json:"id"`
+	HWID        string  `json:"hwid"`
+	IP          string  `json:"ip"`
+	FirstSeenOn *string `json:"first_seen_on"`
+	LastSeenOn  *string `json:"last_seen_on"`
+}
+
+type BlacklistEntryResponse struct {
+	ID        uint          `json:"id"`
+	Kind      BlacklistKind `json:"kind"`
+	Value     string        `json:"value"`
+	Reason    string        `json:"reason"`
+	AddedBy   string        `json:"added_by"`
+	AddedOn   *string       `json:"added_on"`
+	LicenseID *uint         `json:"license_id,omitempty"`
+}
+
+type HWIDResetResponse struct {
+	HWID        string  `json:"hwid"`
+	IP          string  `json:"ip"`
+	SelfService bool    `json:"self_service"`
+	ResetBy     string  `json:"reset_by"`
+	ResetOn     *string `json:"reset_on"`
+}
+
+// LicenseTokenClaims is the payload of the signed token returned when a license is redeemed
+type LicenseTokenClaims struct {
+	Key           string        `json:"key"`
+	ApplicationID string        `json:"application_id"`
+	HWID          string        `json:"hwid"`
+	Status        LicenseStatus `json:"status"`
+	ExpiresOn     string        `json:"expires_on,omitempty"` // RFC 3339, omitted for lifetime licenses
+	Lifetime      bool          `json:"lifetime"`
+	Entitlements  Entitlements  `json:"entitlements,omitempty"`
+	Nonce         string        `json:"nonce,omitempty"` // Nonce sent by the client with the request
+	IssuedAt      int64         `json:"iat"`
+	Expiry        int64         `json:"exp,omitempty"` // Omitted for lifetime licenses
+}
+
+// LicenseValidationClaims is the payload of the signed token returned when a license is validated
+type LicenseValidationClaims struct {
+	Key           string        `json:"key"`
+	ApplicationID string        `json:"application_id"`
+	HWID          string        `json:"hwid"`
+	Status        LicenseStatus `json:"status"`
+	Valid         bool          `json:"valid"`
+	HWIDMatch     bool          `json:"hwid_match"`
+	Expired       bool          `json:"expired"`
+	InGracePeriod bool          `json:"in_grace_period"`
+	ExpiresOn     string        `json:"expires_on,omitempty"` // RFC 3339, omitted for lifetime licenses
+	Lifetime      bool          `json:"lifetime"`
+	Nonce         string        `json:"nonce,omitempty"` // Nonce sent by the client with the request
+	IssuedAt      int64         `json:"iat"`
+}
+
+type LicenseJobResponse struct {
+	JobID        string           `json:"job_id"`
+	Status       LicenseJobStatus `json:"status"`
+	Total        int              `json:"total"`
+	Generated    int              `json:"generated"`
+	Error        string           `json:"error,omitempty"`
+	CreatedOn    *string          `json:"created_on"`
+	CompletedOn  *string          `json:"completed_on"`
+	KeysExpireOn *string          `json:"keys_expire_on"` // The keys can be downloaded until then
+}
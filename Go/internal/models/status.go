@@ -0,0 +1,56 @@
+package models
+
+import "fmt"
+
+// LicenseStatus is the lifecycle state of a license
+type LicenseStatus string
+
+// Stored values predate the typed status, so an active license is still stored as "Used"
+const (
+	LicenseNotUsed LicenseStatus = "Not Used"
+	LicenseActive  LicenseStatus = "Used"
+	LicenseExpired LicenseStatus = "Expired"
+	LicenseBanned  LicenseStatus = "Banned"
+	LicenseFrozen  LicenseStatus = "Frozen"
+	LicenseRevoked LicenseStatus = "Revoked"
+)
+
+// licenseTransitions lists the statuses each status may move to. Banned and frozen licenses
+// return to the status they had before, and revoked licenses are final.
+var licenseTransitions = map[LicenseStatus][]LicenseStatus{
+	LicenseNotUsed: {LicenseActive, LicenseBanned, LicenseFrozen, LicenseRevoked},
+	LicenseActive:  {LicenseExpired, LicenseBanned, LicenseFrozen, LicenseRevoked},
+	LicenseExpired: {LicenseActive, LicenseBanned, LicenseRevoked},
+	LicenseBanned:  {LicenseNotUsed, LicenseActive, LicenseExpired, LicenseFrozen, LicenseRevoked},
+	LicenseFrozen:  {LicenseNotUsed, LicenseActive, LicenseBanned, LicenseRevoked},
+	LicenseRevoked: {},
+}
+
+// StatusTransitionError is returned when a license cannot move from its status to the requested one
+type StatusTransitionError struct {
+	From LicenseStatus
+	To   LicenseStatus
+}
+
+func (e *StatusTransitionError) Error() string {
+	return fmt.Sprintf("license cannot move from %q to %q", e.From, e.To)
+}
+
+// CanTransition reports whether a license with this status may move to the given status
+func (s LicenseStatus) CanTransition(to LicenseStatus) bool {
+	for _, allowed := range licenseTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition moves the license to the given status, it does not save the license
+func (l *License) Transition(to LicenseStatus) error {
+	if !l.Status.CanTransition(to) {
+		return &StatusTransitionError{From: l.Status, To: to}
+	}
+	l.Status = to
+	return nil
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// KeyChecksumVersion is the version of the checksum segment appended to newly generated keys.
+// Version 1 is "K1" followed by the first 20 bits of HMAC-SHA256 as 4 Crockford base32 characters,
+// keyed with the secret string and computed over everything before the final dash of the key.
+const KeyChecksumVersion = 1
+
+const keyChecksumLength = 4
+
+// GenerateChecksumSecret creates a random secret for key checksums encoded as standard base64
+func GenerateChecksumSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(secret), nil
+}
+
+// AppendKeyChecksum adds the checksum segment of the current version to a key
+func AppendKeyChecksum(secret string, key string) string {
+	return key + "-" + keyChecksum(secret, key, KeyChecksumVersion)
+}
+
+// VerifyKeyChecksum checks the checksum segment of a key and returns the key without it.
+// ok is false when the key has no checksum segment or the checksum does not match.
+func VerifyKeyChecksum(secret string, key string) (body string, ok bool) {
+	i := strings.LastIndexByte(key, '-')
+	if i < 0 {
+		return "", false
+	}
+	body, segment := key[:i], key[i+1:]
+
+	// Only version 1 exists, the version digit lets later schemes coexist with it
+	if len(segment) != 2+keyChecksumLength || segment[:2] != fmt.Sprintf("K%d", KeyChecksumVersion) {
+		return "", false
+	}
+	if !hmac.Equal([]byte(segment), []byte(keyChecksum(secret, body, KeyChecksumVersion))) {
+		return "", false
+	}
+	return body, true
+}
+
+func keyChecksum(secret string, key string, version int) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(key))
+	sum := mac.Sum(nil)
+
+	alphabet := keyAlphabets[KeyAlphabetCrockford]
+	bits := uint32(sum[0])<<16 | uint32(sum[1])<<8 | uint32(sum[2])
+	checksum := make([]byte, keyChecksumLength)
+	for i := range checksum {
+		checksum[i] = alphabet[(bits>>(19-5*i))&31]
+	}
+	return fmt.Sprintf("K%d%s", version, checksum)
+}
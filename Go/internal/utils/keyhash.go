@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// keyHintLength is the number of trailing key characters kept in the hint
+const keyHintLength = 4
+
+// minKeyPepperLength is the shortest LICENSE_KEY_PEPPER accepted, short enough for "openssl rand -base64 32"
+const minKeyPepperLength = 32
+
+var (
+	keyPepper     []byte
+	keyPepperOnce sync.Once
+)
+
+// licenseKeyPepper reads the secret keys are hashed with from LICENSE_KEY_PEPPER.
+// Changing it makes every stored key unredeemable, so it must stay the same across restarts.
+func licenseKeyPepper() []byte {
+	keyPepperOnce.Do(func() {
+		keyPepper = []byte(os.Getenv("LICENSE_KEY_PEPPER"))
+	})
+	return keyPepper
+}
+
+// CheckLicenseKeyPepper returns an error when LICENSE_KEY_PEPPER is missing or too short to keep
+// key hashes and sealed keys safe if the database leaks. The server must not start without it.
+func CheckLicenseKeyPepper() error {
+	pepper := licenseKeyPepper()
+	if len(pepper) == 0 {
+		return errors.New("LICENSE_KEY_PEPPER is not set")
+	}
+	if len(pepper) < minKeyPepperLength {
+		return fmt.Errorf("LICENSE_KEY_PEPPER must be at least %d characters", minKeyPepperLength)
+	}
+	return nil
+}
+
+// HashLicenseKey returns the hex encoded HMAC-SHA256 of a license key, which is what gets stored and looked up
+func HashLicenseKey(key string) string {
+	mac := hmac.New(sha256.New, licenseKeyPepper())
+	mac.Write([]byte(key))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// LicenseKeyHint returns the prefix and the last characters of a key, e.g. "PRO-****7QX2", for telling keys apart
+func LicenseKeyHint(key string) string {
+	prefix := ""
+	if i := strings.IndexByte(key, '-'); i >= 0 {
+		prefix = key[:i+1]
+	}
+	if len(key)-len(prefix) <= keyHintLength {
+		return prefix + "****"
+	}
+	return prefix + "****" + key[len(key)-keyHintLength:]
+}
+
+// derivedStream is an endless stream of HMAC-SHA256 blocks over a seed and a counter, keyed with the pepper
+type derivedStream struct {
+	seed    string
+	counter uint64
+	buffer  []byte
+}
+
+func newDerivedStream(seed string) *derivedStream {
+	return &derivedStream{seed: seed}
+}
+
+func (stream *derivedStream) Read(p []byte) (int, error) {
+	for n := 0; n < len(p); {
+		if len(stream.buffer) == 0 {
+			mac := hmac.New(sha256.New, licenseKeyPepper())
+			mac.Write([]byte(stream.seed))
+			binary.Write(mac, binary.BigEndian, stream.counter)
+			stream.buffer = mac.Sum(nil)
+			stream.counter++
+		}
+		copied := copy(p[n:], stream.buffer)
+		stream.buffer = stream.buffer[copied:]
+		n += copied
+	}
+	return len(p), nil
+}
+
+// licenseKeysCipher returns the AES-256-GCM cipher keys awaiting download are sealed with, derived from the pepper
+func licenseKeysCipher() (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, licenseKeyPepper())
+	mac.Write([]byte("license keys at rest"))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SealLicenseKeys encrypts plaintext keys that have to be kept until they are downloaded
+func SealLicenseKeys(keys []string) (string, error) {
+	aead, err := licenseKeysCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(strings.Join(keys, "\n")), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// OpenLicenseKeys decrypts keys sealed with SealLicenseKeys
+func OpenLicenseKeys(sealed string) ([]string, error) {
+	aead, err := licenseKeysCipher()
+	if err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("sealed keys are too short")
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(string(plaintext), "\n"), nil
+}
@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// Alphabets the X and A mask tokens draw from, chosen per application
+const (
+	KeyAlphabetDefault   = "default"
+	KeyAlphabetCrockford = "crockford"
+)
+
+var keyAlphabets = map[string]string{
+	KeyAlphabetDefault:   "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789",
+	KeyAlphabetCrockford: "0123456789ABCDEFGHJKMNPQRSTVWXYZ", // Leaves out I, L, O and U, which are easily confused
+}
+
+const (
+	digitChars = "0123456789"
+	hexChars   = "0123456789ABCDEF"
+)
+
+var maskLiteralPattern = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+// maxMaskedKeyLength keeps generated keys within the license key column together with the prefix
+const maxMaskedKeyLength = 64
+
+// MinKeyEntropyBits is the entropy every generated key needs on top of what the batch size requires
+const MinKeyEntropyBits = 32
+
+// KeyMask is a parsed license mask made of these tokens:
+//
+//	X      a character of the application's alphabet
+//	9      a digit
+//	A      a letter of the application's alphabet
+//	H      a hexadecimal digit
+//	-      a separator between groups
+//	{PRO}  the literal characters PRO
+type KeyMask struct {
+	tokens  []keyMaskToken
+	pattern *regexp.Regexp
+}
+
+// keyMaskToken is either a set of characters one random character is drawn from, or literal text
+type keyMaskToken struct {
+	chars   string
+	literal string
+}
+
+// ParseKeyMask parses a license mask for the given alphabet, an empty alphabet selects the default one
+func ParseKeyMask(mask string, alphabet string) (*KeyMask, error) {
+	if alphabet == "" {
+		alphabet = KeyAlphabetDefault
+	}
+	alphabetChars, ok := keyAlphabets[alphabet]
+	if !ok {
+		return nil, fmt.Errorf("unknown key alphabet %q", alphabet)
+	}
+
+	if mask == "" || strings.HasPrefix(mask, "-") || strings.HasSuffix(mask, "-") || strings.Contains(mask, "--") {
+		return nil, fmt.Errorf("mask must be groups of tokens separated by single dashes")
+	}
+
+	keyMask := &KeyMask{}
+	pattern := "^[A-Za-z0-9]+-"
+	length := 0
+	for i := 0; i < len(mask); i++ {
+		token := keyMaskToken{}
+		switch mask[i] {
+		case 'X':
+			token.chars = alphabetChars
+		case '9':
+			token.chars = digitChars
+		case 'A':
+			token.chars = strings.Map(func(char rune) rune {
+				if strings.ContainsRune(digitChars, char) {
+					return -1
+				}
+				return char
+			}, alphabetChars)
+		case 'H':
+			token.chars = hexChars
+		case '-':
+			token.literal = "-"
+		case '{':
+			end := strings.IndexByte(mask[i:], '}')
+			if end < 2 {
+				return nil, fmt.Errorf("unterminated or empty literal in mask")
+			}
+			token.literal = mask[i+1 : i+end]
+			if !maskLiteralPattern.MatchString(token.literal) {
+				return nil, fmt.Errorf("mask literals may only contain letters and digits")
+			}
+			i += end
+		default:
+			return nil, fmt.Errorf("invalid mask token %q", mask[i])
+		}
+
+		if token.chars != "" {
+			pattern += "[" + token.chars + "]"
+			length++
+		} else {
+			pattern += regexp.QuoteMeta(token.literal)
+			length += len(token.literal)
+		}
+		keyMask.tokens = append(keyMask.tokens, token)
+	}
+
+	if length > maxMaskedKeyLength {
+		return nil, fmt.Errorf("mask produces keys longer than %d characters", maxMaskedKeyLength)
+	}
+
+	keyMask.pattern = regexp.MustCompile(pattern + "$")
+	return keyMask, nil
+}
+
+// Generate creates a key with the given prefix, drawing every random character from crypto/rand
+func (keyMask *KeyMask) Generate(prefix string) (string, error) {
+	return keyMask.generate(prefix, rand.Reader)
+}
+
+// Derive creates the key for the given prefix and seed, the same seed always gives the same key.
+// Derived keys are keyed with LICENSE_KEY_PEPPER, so they cannot be derived without it.
+func (keyMask *KeyMask) Derive(prefix string, seed string) string {
+	// The derived stream never fails to read
+	key, _ := keyMask.generate(prefix, newDerivedStream(seed))
+	return key
+}
+
+func (keyMask *KeyMask) generate(prefix string, random io.Reader) (string, error) {
+	var key strings.Builder
+	key.WriteString(prefix)
+	key.WriteByte('-')
+	for _, token := range keyMask.tokens {
+		if token.chars == "" {
+			key.WriteString(token.literal)
+			continue
+		}
+
+		n, err := rand.Int(random, big.NewInt(int64(len(token.chars))))
+		if err != nil {
+			return "", err
+		}
+		key.WriteByte(token.chars[n.Int64()])
+	}
+	return key.String(), nil
+}
+
+// Entropy returns the bits of randomness in a key generated from the mask
+func (keyMask *KeyMask) Entropy() float64 {
+	entropy := 0.0
+	for _, token := range keyMask.tokens {
+		if token.chars != "" {
+			entropy += math.Log2(float64(len(token.chars)))
+		}
+	}
+	return entropy
+}
+
+// Matches reports whether a key, including its prefix, has the format of the mask
+func (keyMask *KeyMask) Matches(key string) bool {
+	return keyMask.pattern.MatchString(key)
+}
+
+// RequiredKeyEntropy returns the entropy keys need when generating amount of them at once,
+// which keeps the chance of a collision within the batch below 2^-32
+func RequiredKeyEntropy(amount int) float64 {
+	return MinKeyEntropyBits + 2*math.Log2(float64(amount))
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// ParseNetwork parses an IPv4 or IPv6 address or CIDR range, a single address becoming a range of just itself
+func ParseNetwork(network string) (netip.Prefix, error) {
+	if strings.Contains(network, "/") {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(network)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// ParseClientIP parses an IP as returned by GetClientIP. IPs stored before the proxy was trusted can hold
+// a whole X-Forwarded-For chain, of which only the last address was added by the proxy.
+func ParseClientIP(ip string) (netip.Addr, error) {
+	if i := strings.LastIndex(ip, ","); i >= 0 {
+		ip = ip[i+1:]
+	}
+	addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return addr.Unmap(), nil
+}
+
+// IPInNetworks reports whether an IP is inside any of the networks. Networks that do not parse match nothing.
+func IPInNetworks(ip string, networks []string) bool {
+	addr, err := ParseClientIP(ip)
+	if err != nil {
+		return false
+	}
+	for _, network := range networks {
+		prefix, err := ParseNetwork(network)
+		if err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// SameSubnet reports whether two IPs share a subnet, using the IPv4 or IPv6 prefix length that fits them.
+// IPs of different families never share a subnet.
+func SameSubnet(a string, b string, ipv4Bits int, ipv6Bits int) bool {
+	addrA, err := ParseClientIP(a)
+	if err != nil {
+		return false
+	}
+	addrB, err := ParseClientIP(b)
+	if err != nil || addrA.Is4() != addrB.Is4() {
+		return false
+	}
+
+	bits := ipv6Bits
+	if addrA.Is4() {
+		bits = ipv4Bits
+	}
+	prefix, err := addrA.Prefix(bits)
+	if err != nil {
+		return false
+	}
+	return prefix.Contains(addrB)
+}
+
+// ValidateNetworks returns an error naming the first entry that is not an IP address or CIDR range
+func ValidateNetworks(networks []string) error {
+	for _, network := range networks {
+		if _, err := ParseNetwork(network); err != nil {
+			return fmt.Errorf("invalid IP address or CIDR range %q", network)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// tokenHeader is the fixed JWS header used for all license tokens
+var tokenHeader = map[string]string{"alg": "EdDSA", "typ": "JWT"}
+
+// GenerateSigningKeyPair creates a new Ed25519 keypair encoded as standard base64 strings.
+func GenerateSigningKeyPair() (string, string, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(publicKey), base64.StdEncoding.EncodeToString(privateKey), nil
+}
+
+// SignToken serializes the claims and signs them as a compact JWS using the base64 encoded Ed25519 private key.
+func SignToken(privateKey string, claims interface{}) (string, error) {
+	keyBytes, err := base64.StdEncoding.DecodeString(privateKey)
+	if err != nil || len(keyBytes) != ed25519.PrivateKeySize {
+		return "", fmt.Errorf("invalid signing key")
+	}
+
+	headerJSON, err := json.Marshal(tokenHeader)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+	signature := ed25519.Sign(ed25519.PrivateKey(keyBytes), []byte(signingInput))
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LegacyDatetimeLayout is the format timestamps were stored in before they became time values.
+// It is still served to clients that ask for it with ?time_format=legacy.
+const LegacyDatetimeLayout = "2006-01-02 @ 03:04 PM"
+
+// TimeFormatter renders timestamps in API responses, as RFC 3339 in UTC by default
+type TimeFormatter struct {
+	Legacy bool // Use LegacyDatetimeLayout in the server's local time zone
+}
+
+// NewTimeFormatter picks the timestamp format requested through the time_format query parameter
+func NewTimeFormatter(ctx *gin.Context) TimeFormatter {
+	return TimeFormatter{Legacy: ctx.Query("time_format") == "legacy"}
+}
+
+// Format renders a timestamp, unset timestamps are rendered as null
+func (f TimeFormatter) Format(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+
+	formatted := t.UTC().Format(time.RFC3339)
+	if f.Legacy {
+		formatted = t.Local().Format(LegacyDatetimeLayout)
+	}
+	return &formatted
+}
+
+// FormatOr renders a timestamp like Format, but in the legacy format an unset timestamp
+// is rendered as the given placeholder such as "N/A"
+func (f TimeFormatter) FormatOr(t *time.Time, placeholder string) *string {
+	if t == nil && f.Legacy {
+		return &placeholder
+	}
+	return f.Format(t)
+}
@@ -2,7 +2,7 @@ package utils
 
 import (
 	"fmt"
-	"math/rand"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -56,82 +56,97 @@ func GetTokenFromHeader(c *gin.Context) (string, error) {
 	return parts[1], nil
 }
 
-func GenerateLicenseKey(prefix string, mask string) string {
-	key := []int32{}
-	for _, char := range mask {
-		if char == 'X' {
-			key = append(key, randomChar())
-		} else if char == '-' {
-			key = append(key, '-')
-		} else {
-			key = append(key, char)
+// AddDurationText adds every part of a duration text such as "1 Days(s) + 2 Weeks(s)" to a time
+func AddDurationText(t time.Time, durationText string) (time.Time, error) {
+	for _, part := range strings.Split(durationText, " + ") {
+		fields := strings.Split(part, " ")
+		if len(fields) != 2 {
+			return t, fmt.Errorf("invalid duration format")
 		}
-	}
-	return fmt.Sprintf("%s-%s", prefix, string(key))
-}
-
-func randomChar() int32 {
-	const chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	return int32(chars[rand.Intn(len(chars))])
-}
-
-func GetCurrentDatetime() string {
-	return time.Now().Format("2006-01-02 @ 03:04 PM")
-}
 
-// CalculateExpiryDateFromText calculates the expiry date from a text like "1 days"
-func CalculateExpiryDateFromText(durationText string) string {
-	layout := "2006-01-02 @ 03:04 PM"
-	currentDateTime := GetCurrentDatetime()
-	currentTime, err := time.Parse(layout, currentDateTime)
-	if err != nil {
-		fmt.Println(err)
-		return ""
-	}
+		amount, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return t, err
+		}
 
-	parts := strings.Split(durationText, " ")
-	if len(parts) != 2 {
-		fmt.Println("invalid duration format")
-		return ""
+		t, err = AddDuration(t, amount, fields[1])
+		if err != nil {
+			return t, err
+		}
 	}
+	return t, nil
+}
 
-	duration, err := strconv.Atoi(parts[0])
-	if err != nil {
-		fmt.Println(err)
-		return ""
+// AddDuration adds an amount of the given unit such as "Days" or "Days(s)" to a time
+func AddDuration(t time.Time, amount int, unit string) (time.Time, error) {
+	switch normalizeUnit(unit) {
+	case "day":
+		return t.AddDate(0, 0, amount), nil
+	case "week":
+		return t.AddDate(0, 0, amount*7), nil
+	case "month":
+		return t.AddDate(0, amount, 0), nil
+	case "year":
+		return t.AddDate(amount, 0, 0), nil
+	case "hour":
+		return t.Add(time.Duration(amount) * time.Hour), nil
+	case "minute":
+		return t.Add(time.Duration(amount) * time.Minute), nil
+	default:
+		return t, fmt.Errorf("unsupported unit")
 	}
+}
 
-	unit := parts[1]
-	switch strings.ToLower(unit) {
-	case "day", "days":
-		currentTime = currentTime.AddDate(0, 0, duration)
-	case "week", "weeks":
-		currentTime = currentTime.AddDate(0, 0, duration*7)
-	case "month", "months":
-		currentTime = currentTime.AddDate(0, duration, 0)
-	case "year", "years":
-		currentTime = currentTime.AddDate(duration, 0, 0)
-	case "hour", "hours":
-		currentTime = currentTime.Add(time.Duration(duration) * time.Hour)
-	case "minute", "minutes":
-		currentTime = currentTime.Add(time.Duration(duration) * time.Minute)
-	default:
-		fmt.Println("unsupported unit")
-		return ""
+// ExtendDurationText adds an amount of a unit to a duration text, merging it into an existing part with the same unit
+func ExtendDurationText(durationText string, amount int, unit string) string {
+	parts := strings.Split(durationText, " + ")
+	for i, part := range parts {
+		fields := strings.Split(part, " ")
+		if len(fields) != 2 || normalizeUnit(fields[1]) != normalizeUnit(unit) {
+			continue
+		}
+		if existing, err := strconv.Atoi(fields[0]); err == nil {
+			parts[i] = fmt.Sprintf("%d %s", existing+amount, fields[1])
+			return strings.Join(parts, " + ")
+		}
 	}
+	return durationText + " + " + FormatDuration(amount, unit)
+}
 
-	return currentTime.Format(layout)
+// normalizeUnit maps "Days(s)", "Days" and "day" to "day"
+func normalizeUnit(unit string) string {
+	unit = strings.ToLower(strings.TrimSuffix(unit, "(s)"))
+	return strings.TrimSuffix(unit, "s")
 }
 
+// GetClientIP returns the IP of the client. Forwarding headers are only honoured when the request
+// comes from one of the router's trusted proxies, see ConfigureTrustedProxies.
 func GetClientIP(ctx *gin.Context) string {
-	ip := ctx.Request.Header.Get("X-Forwarded-For")
-	if ip == "" {
-		ip = ctx.Request.Header.Get("X-Real-IP")
+	return ctx.ClientIP()
+}
+
+// defaultTrustedProxies only covers a proxy on the same host. Trusting whole private ranges would let any client
+// on them set its own IP, so proxies on other hosts or docker networks have to be listed in TRUSTED_PROXIES.
+const defaultTrustedProxies = "127.0.0.1,::1"
+
+// ConfigureTrustedProxies makes the router take the client IP from X-Real-IP, which the proxy sets from the
+// address that connected to it, and otherwise from the X-Forwarded-For entries added by trusted proxies.
+// The proxies are read from TRUSTED_PROXIES as a comma separated list of IPs and CIDR ranges.
+func ConfigureTrustedProxies(router *gin.Engine) error {
+	proxies := os.Getenv("TRUSTED_PROXIES")
+	if proxies == "" {
+		proxies = defaultTrustedProxies
 	}
-	if ip == "" {
-		ip = ctx.ClientIP()
+
+	var trusted []string
+	for _, proxy := range strings.Split(proxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trusted = append(trusted, proxy)
+		}
 	}
-	return ip
+
+	router.RemoteIPHeaders = []string{"X-Real-IP", "X-Forwarded-For"}
+	return router.SetTrustedProxies(trusted)
 }
 
 func FormatDuration(duration int, unit string) string {
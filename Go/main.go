@@ -9,6 +9,7 @@ import (
 	"backend/internal/controllers"
 	"backend/internal/middleware"
 	"backend/internal/models"
+	"backend/internal/utils"
 
 	"github.com/fasthttp/router"
 	"github.com/gin-gonic/gin"
@@ -24,6 +25,11 @@ import (
 var ctx = context.Background()
 
 func main() {
+	// License keys are hashed and sealed with the pepper, so nothing may be stored without it
+	if err := utils.CheckLicenseKeyPepper(); err != nil {
+		panic(err.Error())
+	}
+
 	// Initialize Redis client
 	redisAddr := os.Getenv("REDIS_ADDR")
 	redisPassword := os.Getenv("REDIS_PASSWORD")
@@ -52,10 +58,29 @@ func main() {
 	if err != nil {
 		panic("failed to connect database")
 	}
-	db.AutoMigrate(&models.Application{}, &models.License{})
+	migration, err := models.Migrate(db)
+	if err != nil {
+		panic(fmt.Sprintf("failed to migrate database: %v", err))
+	}
+
+	// Cached license lists from before keys were hashed hold plaintext keys
+	if migration.HashedKeys > 0 {
+		iter := redisClient.Scan(ctx, 0, "application:*:licenses", 0).Iterator()
+		for iter.Next(ctx) {
+			redisClient.Del(ctx, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			fmt.Printf("Failed to clear cached licenses: %v\n", err)
+		}
+	}
+
+	controllers.StartLicenseJobWorker(db, redisClient)
 
 	// Create a new Gin router
 	r := gin.Default()
+	if err := utils.ConfigureTrustedProxies(r); err != nil {
+		panic(fmt.Sprintf("invalid TRUSTED_PROXIES: %v", err))
+	}
 	r.Use(middleware.CORSMiddleware(), middleware.SecurityHeadersMiddleware(), middleware.CSPMiddleware())
 
 	// Inject Redis client into the Gin context
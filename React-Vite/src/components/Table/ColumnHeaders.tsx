@@ -10,8 +10,8 @@ interface LicenseColumnInterface {
 export const licenseColumn: LicenseColumnInterface[] = [
   {
     label: "Key",
-    key: "key",
-    render: (item: LicenseItem) => <span>{item.key}</span>,
+    key: "key_hint",
+    render: (item: LicenseItem) => <span>{item.key_hint}</span>,
   },
   { label: "Creation Date", key: "created_on" },
   { label: "Generated By", key: "generated_by" },
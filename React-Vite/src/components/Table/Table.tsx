@@ -62,13 +62,13 @@ const Table: React.FC<TableProps> = ({
           />
           <TransitionGroup component="tbody">
             {currentData.map((item) => (
-              <CSSTransition key={item.key} timeout={300} classNames="fade">
+              <CSSTransition key={item.id} timeout={300} classNames="fade">
                 <TableRow
-                  key={item.key}
+                  key={item.id}
                   item={item}
                   columns={columns}
                   onSelect={handleSelectLicense}
-                  isSelected={selectedLicenses.value.includes(item.key)}
+                  isSelected={selectedLicenses.value.includes(String(item.id))}
                 />
               </CSSTransition>
             ))}
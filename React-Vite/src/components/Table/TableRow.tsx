@@ -9,7 +9,7 @@ interface TableRowProps {
     label: string;
     render?: (item: any) => React.ReactNode;
   }[];
-  onSelect: (key: string, checked: boolean) => void;
+  onSelect: (licenseId: string, checked: boolean) => void;
   isSelected: boolean;
 }
 
@@ -32,7 +32,7 @@ const TableRow: React.FC<TableRowProps> = ({
 
     try {
       await navigator.clipboard.writeText(text);
-      showToast({ message: "License copied to clipboard", type: "success" });
+      showToast({ message: "Key hint copied to clipboard", type: "success" });
     } catch (err) {
       console.error("Failed to copy text: ", err);
     }
@@ -42,11 +42,11 @@ const TableRow: React.FC<TableRowProps> = ({
     <tr className="bg-[#333b45] hover:bg-[#38404A] border-transparent">
       <th>
         <input
-          id={`select-${item.key}`}
+          id={`select-${item.id}`}
           type="checkbox"
           className="checkbox border-blue-400 [--chkbg:theme(colors.blue.400)] [--chkfg:white] checked:border-blue-400"
           checked={isSelected}
-          onChange={(e) => onSelect(item.key, e.target.checked)}
+          onChange={(e) => onSelect(String(item.id), e.target.checked)}
         />
       </th>
       {columns.map((column) => {
@@ -57,7 +57,7 @@ const TableRow: React.FC<TableRowProps> = ({
         return (
           <td
             key={column.key}
-            onClick={column.key === "key" ? handleCellClick : undefined}
+            onClick={column.key === "key_hint" ? handleCellClick : undefined}
             className={`${shouldBlur ? "blurred-text" : ""} ${
               isBanned ? "text-red-500" : ""
             }`}
@@ -77,7 +77,7 @@ const TableRow: React.FC<TableRowProps> = ({
                 className="text-red-500"
                 onClick={async (event) => {
                   event.preventDefault();
-                  await handleDeleteLicense(item.key);
+                  await handleDeleteLicense(String(item.id));
                 }}
               >
                 Delete
@@ -86,7 +86,7 @@ const TableRow: React.FC<TableRowProps> = ({
                 className="text-red-500"
                 onClick={async (event) => {
                   event.preventDefault();
-                  await handleBanLicense(item.key);
+                  await handleBanLicense(String(item.id));
                 }}
               >
                 Ban
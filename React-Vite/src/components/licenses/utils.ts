@@ -10,7 +10,8 @@ export const currentPage = signal(1);
 export const selectedLicenses = signal<string[]>([]);
 
 export interface LicenseItem {
-  key: string;
+  id: number;
+  key_hint: string;
   created_on: string;
   generated_by: string;
   duration: string;
@@ -114,13 +115,13 @@ export const handleDeleteSelectedLicenses = () => {
   );
 };
 
-export const handleDeleteLicense = (key: string) => {
+export const handleDeleteLicense = (licenseId: string) => {
   return handleApiRequest(
     () =>
       axios.delete(
         `${import.meta.env.VITE_PUBLIC_URL}api/v1/private/applications/${
           selectedApplicationID.value
-        }/licenses/${key}`,
+        }/licenses/${licenseId}`,
         {
           headers: {
             Accept: "application/json",
@@ -133,14 +134,14 @@ export const handleDeleteLicense = (key: string) => {
   );
 };
 
-export const handleBanLicense = (key: string) => {
+export const handleBanLicense = (licenseId: string) => {
   return handleApiRequest(
     () =>
       axios.patch(
         `${import.meta.env.VITE_PUBLIC_URL}api/v1/private/applications/${
           selectedApplicationID.value
-        }/licenses/${key}/ban`,
-        { key },
+        }/licenses/${licenseId}/ban`,
+        { key: licenseId },
         {
           headers: {
             Accept: "application/json",
@@ -153,10 +154,10 @@ export const handleBanLicense = (key: string) => {
   );
 };
 
-export const handleSelectLicense = (key: string, selected: boolean) => {
+export const handleSelectLicense = (licenseId: string, selected: boolean) => {
   selectedLicenses.value = selected
-    ? [...selectedLicenses.value, key]
-    : selectedLicenses.value.filter((item) => item !== key);
+    ? [...selectedLicenses.value, licenseId]
+    : selectedLicenses.value.filter((item) => item !== licenseId);
 };
 
 export const handleSelectAll = (
@@ -164,14 +165,14 @@ export const handleSelectAll = (
   currentData: LicenseItem[]
 ) => {
   if (selected) {
-    const currentKeys = currentData.map((item) => item.key);
+    const currentIds = currentData.map((item) => String(item.id));
     selectedLicenses.value = [
-      ...new Set([...selectedLicenses.value, ...currentKeys]),
+      ...new Set([...selectedLicenses.value, ...currentIds]),
     ];
   } else {
-    const currentKeys = currentData.map((item) => item.key);
+    const currentIds = currentData.map((item) => String(item.id));
     selectedLicenses.value = selectedLicenses.value.filter(
-      (key) => !currentKeys.includes(key)
+      (id) => !currentIds.includes(id)
     );
   }
 };
@@ -204,5 +205,16 @@ export const handleGenerateLicense = async () => {
     license_duration: licenseDuration,
   };
 
-  await generateLicense(data);
+  const response = await generateLicense(data);
+
+  // Keys are only stored hashed, so this is the only time they can be copied
+  const keys = response.data.licenses.map(
+    (license: { key: string }) => license.key
+  );
+  try {
+    await navigator.clipboard.writeText(keys.join("\n"));
+    showSuccessToast("Generated keys copied to clipboard");
+  } catch (err) {
+    console.error("Failed to copy keys: ", err);
+  }
 };
@@ -17,7 +17,7 @@ const fetchKeycloakData = async () => {
 
   try {
     const response = await axios.get(
-      `${import.meta.env.VITE_PUBLIC_URL}api/v1/private/applications/data`,
+      `${import.meta.env.VITE_PUBLIC_URL}api/v1/private/applications/data?time_format=legacy`,
       {
         headers: {
           Authorization: `Bearer ${keycloak.token}`,
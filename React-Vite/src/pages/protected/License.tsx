@@ -33,14 +33,14 @@ const LicensesPage = () => {
         data?.applicationsData?.some(
           (app: Application) =>
             app.app_name === selectedApplication.value &&
-            app.licenses?.some((lic: LicenseItem) => lic.key === license.key)
+            app.licenses?.some((lic: LicenseItem) => lic.id === license.id)
         )
       )
     : licensesData;
 
   const { filteredData, searchTerm } = useFilteredData(
     filteredLicenses,
-    (license) => license.key
+    (license) => license.key_hint
   );
 
   if (isLoading) {